@@ -1,58 +1,306 @@
 // © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
 
-// inertia prints the inertial status of each predicate.
-// The table that is printed, for each domain, mimics
-// that of Figure 5 in:
+// inertia prints an analysis report for each domain.
+//
+// Usage:
+//
+//	inertia [-format text|json|dot] <file>...
+//
+// Each file contains one or more domains and problems, and it
+// may be compressed with gzip or bzip2.  Problems are reported
+// against the most recent preceding domain.
+//
+// The report for a domain contains the inertial status
+// of each predicate, the number of static predicates, the
+// predicates read and written by each action, and the
+// actions that each action can enable.  Processes and
+// events are reported along with the actions.  The table of
+// inertial statuses mimics that of Figure 5 in:
 // On the Instantiation of ADL Operators Involving
 // Arbitrary First-Order Formulas, by Koehler and
 // Hoffmann, 2000.
+//
+// The report for a problem contains the number of
+// :init facts for predicates with each inertial status.
+//
+// With -format dot, the report for a domain is a graph
+// with an edge from each predicate to each action that
+// reads it and from each action to each predicate that
+// it writes.  Problems are not reported in DOT.
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"planit/pddl"
+	"strings"
 	"text/tabwriter"
 )
 
+var format = flag.String("format", "text", "output format: text, json, or dot")
+
+// Statuses is the list of inertial statuses
+// in the order that they are reported.
+var statuses = []string{"inertia", "pos. inertia", "neg. inertia", "fluent"}
+
 func main() {
+	flag.Parse()
 	log.SetFlags(0)
-	for _, path := range os.Args[1:] {
-		file, err := os.Open(path)
+	var printDom func(io.Writer, *pddl.Domain)
+	var printProb func(io.Writer, *pddl.Problem)
+	switch *format {
+	case "text":
+		printDom, printProb = printDomainText, printProblemText
+	case "json":
+		printDom, printProb = printDomainJSON, printProblemJSON
+	case "dot":
+		printDom, printProb = printDomainDot, nil
+	default:
+		log.Fatalf("unknown format %s", *format)
+	}
+
+	var dom *pddl.Domain
+	for _, path := range flag.Args() {
+		file, err := pddl.Open(path)
 		if err != nil {
 			log.Println(err)
 			continue
 		}
-		ast, err := pddl.Parse(path, file)
+		asts, err := pddl.ParseAll(path, file)
+		file.Close()
 		if err != nil {
 			log.Println(err)
 		}
-		switch d := ast.(type) {
-		case *pddl.Problem:
-			log.Println(path, "is a problem file, skipping")
-		case *pddl.Domain:
-			if err := pddl.Check(d, nil); err != nil {
-				log.Println(err)
-				continue
-			}
-			fmt.Println(d)
-			w := tabwriter.NewWriter(os.Stdout, 0, 8, 0, '\t', 0)
-			fmt.Fprintln(w, "predicate\tpos. effect\tneg. effect\tstatus")
-			for _, pred := range d.Predicates {
-				status := "inertia"
-				if pred.PosEffect && pred.NegEffect {
-					status = "fluent"
-				} else if pred.PosEffect {
-					status = "neg. inertia"
-				} else if pred.NegEffect {
-					status = "pos. inertia"
+		for _, ast := range asts {
+			switch r := ast.(type) {
+			case *pddl.Problem:
+				if dom == nil {
+					log.Println("problem", r.Name, "has no preceding domain, skipping")
+					continue
+				}
+				if errs := pddl.Check(dom, r); len(errs) > 0 {
+					log.Println(errs)
+					continue
+				}
+				if printProb != nil {
+					printProb(os.Stdout, r)
+				}
+			case *pddl.Domain:
+				dom = nil
+				if errs := pddl.Check(r, nil); len(errs) > 0 {
+					log.Println(errs)
+					continue
 				}
-				fmt.Fprintf(w, "%s\t%t\t%t\t%s\n", pred,
-					pred.PosEffect, pred.NegEffect, status)
+				dom = r
+				printDom(os.Stdout, r)
 			}
-			w.Flush()
 		}
-		file.Close()
 	}
 }
+
+// Status returns the inertial status of a predicate.
+func status(pred *pddl.Predicate) string {
+	switch {
+	case pred.PosEffect && pred.NegEffect:
+		return "fluent"
+	case pred.PosEffect:
+		return "neg. inertia"
+	case pred.NegEffect:
+		return "pos. inertia"
+	}
+	return "inertia"
+}
+
+// NumStatic returns the number of predicates that appear in no effect.
+func numStatic(d *pddl.Domain) (n int) {
+	for i := range d.Predicates {
+		if status(&d.Predicates[i]) == "inertia" {
+			n++
+		}
+	}
+	return
+}
+
+// Enabled returns the actions, processes, and events
+// of the domain that the action can enable.
+func enabled(d *pddl.Domain, act *pddl.Action) (acts []*pddl.Action) {
+	for _, a := range d.Happenings() {
+		if act.Enables(a) {
+			acts = append(acts, a)
+		}
+	}
+	return
+}
+
+// InitCounts returns the number of :init facts of
+// the problem for predicates with each inertial status.
+// Timed initial literals are counted with the other facts.
+func initCounts(p *pddl.Problem) map[string]int {
+	counts := make(map[string]int, len(statuses))
+	for _, s := range statuses {
+		counts[s] = 0
+	}
+	for _, f := range p.Init {
+		if t, ok := f.(*pddl.TimedNode); ok {
+			f = t.Formula
+		}
+		if lit, ok := f.(*pddl.LiteralNode); ok && lit.Definition != nil {
+			counts[status(lit.Definition)]++
+		}
+	}
+	return counts
+}
+
+func printDomainText(w io.Writer, d *pddl.Domain) {
+	fmt.Fprintln(w, d)
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "predicate\tpos. effect\tneg. effect\tstatus")
+	for i := range d.Predicates {
+		pred := &d.Predicates[i]
+		fmt.Fprintf(tw, "%s\t%t\t%t\t%s\n", pred,
+			pred.PosEffect, pred.NegEffect, status(pred))
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "%d of %d predicates are static\n", numStatic(d), len(d.Predicates))
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "action\treads\twrites\tenables")
+	for _, act := range d.Happenings() {
+		var en []string
+		for _, a := range enabled(d, act) {
+			en = append(en, a.Str)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", act,
+			predList(act.Reads()), predList(act.Writes()), strings.Join(en, " "))
+	}
+	tw.Flush()
+}
+
+// PredList returns a space-separated list of the predicate names.
+func predList(preds []*pddl.Predicate) string {
+	return strings.Join(predNames(preds), " ")
+}
+
+func printProblemText(w io.Writer, p *pddl.Problem) {
+	fmt.Fprintf(w, "\n%s\n", p)
+	counts := initCounts(p)
+	tw := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "status\tinit facts")
+	for _, s := range statuses {
+		fmt.Fprintf(tw, "%s\t%d\n", s, counts[s])
+	}
+	tw.Flush()
+}
+
+type (
+	// DomainReport is the JSON report for a domain.
+	domainReport struct {
+		Domain     string            `json:"domain"`
+		Predicates []predicateReport `json:"predicates"`
+		Static     int               `json:"static"`
+		Actions    []actionReport    `json:"actions"`
+	}
+
+	// PredicateReport is the JSON report for a predicate.
+	predicateReport struct {
+		Name      string `json:"name"`
+		PosEffect bool   `json:"posEffect"`
+		NegEffect bool   `json:"negEffect"`
+		Status    string `json:"status"`
+	}
+
+	// ActionReport is the JSON report for an action.
+	actionReport struct {
+		Name    string   `json:"name"`
+		Reads   []string `json:"reads"`
+		Writes  []string `json:"writes"`
+		Enables []string `json:"enables"`
+	}
+
+	// ProblemReport is the JSON report for a problem.
+	problemReport struct {
+		Problem   string         `json:"problem"`
+		Domain    string         `json:"domain"`
+		InitFacts map[string]int `json:"initFacts"`
+	}
+)
+
+func printDomainJSON(w io.Writer, d *pddl.Domain) {
+	r := domainReport{
+		Domain:     d.Str,
+		Predicates: []predicateReport{},
+		Static:     numStatic(d),
+		Actions:    []actionReport{},
+	}
+	for i := range d.Predicates {
+		pred := &d.Predicates[i]
+		r.Predicates = append(r.Predicates, predicateReport{
+			Name:      pred.Str,
+			PosEffect: pred.PosEffect,
+			NegEffect: pred.NegEffect,
+			Status:    status(pred),
+		})
+	}
+	for _, act := range d.Happenings() {
+		a := actionReport{
+			Name:    act.Str,
+			Reads:   predNames(act.Reads()),
+			Writes:  predNames(act.Writes()),
+			Enables: []string{},
+		}
+		for _, e := range enabled(d, act) {
+			a.Enables = append(a.Enables, e.Str)
+		}
+		r.Actions = append(r.Actions, a)
+	}
+	writeJSON(w, r)
+}
+
+// PredNames returns a non-nil slice of the predicate names.
+func predNames(preds []*pddl.Predicate) []string {
+	names := []string{}
+	for _, p := range preds {
+		names = append(names, p.Str)
+	}
+	return names
+}
+
+func printProblemJSON(w io.Writer, p *pddl.Problem) {
+	writeJSON(w, problemReport{
+		Problem:   p.Str,
+		Domain:    p.Domain.Str,
+		InitFacts: initCounts(p),
+	})
+}
+
+func writeJSON(w io.Writer, v interface{}) {
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(w, "%s\n", b)
+}
+
+func printDomainDot(w io.Writer, d *pddl.Domain) {
+	fmt.Fprintf(w, "digraph %q {\n", d.Str)
+	for i := range d.Predicates {
+		pred := &d.Predicates[i]
+		fmt.Fprintf(w, "\t%q [shape=box, label=%q];\n",
+			"pred "+pred.Str, pred.Str+"\n"+status(pred))
+	}
+	for _, act := range d.Happenings() {
+		fmt.Fprintf(w, "\t%q [label=%q];\n", "act "+act.Str, act.Str)
+		for _, p := range act.Reads() {
+			fmt.Fprintf(w, "\t%q -> %q;\n", "pred "+p.Str, "act "+act.Str)
+		}
+		for _, p := range act.Writes() {
+			fmt.Fprintf(w, "\t%q -> %q;\n", "act "+act.Str, "pred "+p.Str)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
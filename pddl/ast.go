@@ -29,6 +29,12 @@ type Domain struct {
 
 	// Actions is the action definitions.
 	Actions []Action
+
+	// Processes is the PDDL+ process definitions.
+	Processes []Action
+
+	// Events is the PDDL+ event definitions.
+	Events []Action
 }
 
 // A Problem represents a PDDL planning problem definition
@@ -72,6 +78,18 @@ const (
 type Name struct {
 	Str string
 	Location
+
+	// key is the case-folded Str, interned by the lexer.
+	// It is empty for names that were not parsed.
+	key string
+}
+
+// Key returns the case-folded name by which names are compared.
+func (n Name) Key() string {
+	if n.key != "" {
+		return n.key
+	}
+	return fold(n.Str)
 }
 
 func (n Name) String() string {
@@ -151,6 +169,8 @@ type Function struct {
 	Num int
 
 	// Types is a disjunctive list of the types for the evaluation of this function.
+	// Numeric functions have the type number, or no type.  Object fluents have
+	// object types.
 	Types []TypeName
 
 	// Parameters is a typed list of the function parameters.
@@ -216,7 +236,24 @@ type LiteralNode struct {
 	Definition *Predicate
 }
 
-// A Term represents either a constant or a variable.
+// An EqualNode represents an equality test between two terms.
+type EqualNode struct {
+	Node
+
+	// Negative is true if this equality is negated.  Negated
+	// equalities only appear in this form in effects and the
+	// :init section; elsewhere negation is represented by a NotNode.
+	Negative bool
+
+	// Left and Right are the terms that are compared.
+	Left, Right Term
+
+	// IsEffect is true if the equality is appearing in an unconditional effect
+	// or as a consequent of a conditional effect, which is not allowed.
+	IsEffect bool
+}
+
+// A Term represents either a constant, a variable, or the application of an object fluent.
 type Term struct {
 	// Name is the name of the term.
 	Name
@@ -226,7 +263,19 @@ type Term struct {
 	Variable bool
 
 	// Definition points to the variable or constant definition for this term.
+	// It is nil if the term is a function application.
 	Definition *TypedEntry
+
+	// Fhead is non-nil if the term is the application of an object fluent,
+	// in which case Name is the name of the function.
+	Fhead *Fhead
+}
+
+func (t Term) String() string {
+	if t.Fhead != nil {
+		return t.Fhead.String()
+	}
+	return t.Str
 }
 
 // An AndNode represents a conjunction of it successors.
@@ -263,12 +312,40 @@ type WhenNode struct {
 	UnaryNode
 }
 
+// A ProbabilisticNode represents a PPDDL probabilistic effect.  Each
+// outcome in the Formula of the MultiNode occurs with the probability
+// of the same index, and no outcome occurs with the remaining probability.
+type ProbabilisticNode struct {
+	// Probabilities are strings representing
+	// the probabilities of the outcomes.
+	Probabilities []string
+
+	MultiNode
+}
+
+// A TimedNode represents a timed initial literal or fluent: a literal
+// or function assignment in the :init section that becomes true at
+// the given time.
+type TimedNode struct {
+	// Time is a string representing the number
+	// of time units after which the Formula holds.
+	Time string
+
+	// The Formula of the UnaryNode is either a
+	// *LiteralNode or an *AssignNode.
+	UnaryNode
+}
+
 var (
 	// AssignOps is the set of valid assignment operators.
+	// Decrease, scale-up, and scale-down require :time.
 	AssignOps = map[string]bool{
-		"=":        true,
-		"assign":   true,
-		"increase": true,
+		"=":          true,
+		"assign":     true,
+		"increase":   true,
+		"decrease":   true,
+		"scale-up":   true,
+		"scale-down": true,
 	}
 )
 
@@ -289,9 +366,23 @@ type AssignNode struct {
 	// Number is valid if IsNumber is true; it is a string representing the assigned number.
 	Number string
 
-	// Fhead is valid if IsNumber is false; it is the assigned function instantiation.
+	// IsTerm is true if the right-hand-side is a constant or a variable, in which case
+	// the Term field is valid and the Fhead field is not.  Constants and variables are
+	// assigned to object fluents.
+	IsTerm bool
+
+	// Term is valid if IsTerm is true; it is the assigned constant or variable.
+	Term Term
+
+	// Fhead is valid if IsNumber and IsTerm are false; it is the assigned function
+	// instantiation.
 	Fhead Fhead
 
+	// Continuous is true if the assignment is a continuous effect of a process:
+	// (increase f (* #t rate)).  The Number or Fhead is the rate of change per
+	// unit of time.  The rate of #t alone is the Number 1.
+	Continuous bool
+
 	// IsInit is true if the assignment is appearing in the :init section of a problem.
 	IsInit bool
 }
@@ -308,6 +399,14 @@ type Fhead struct {
 	Definition *Function
 }
 
+func (h Fhead) String() string {
+	s := "(" + h.Str
+	for _, t := range h.Arguments {
+		s += " " + t.String()
+	}
+	return s + ")"
+}
+
 // Locer wraps the Loc method.
 type Locer interface {
 	Loc() Location
@@ -0,0 +1,90 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// LargeProblem returns a synthetic logistics-like
+// problem with n packages and n locations.
+func largeProblem(n int) string {
+	var b strings.Builder
+	b.WriteString("(define (problem big) (:domain logistics)\n(:objects")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, " pkg%d loc%d", i, i)
+	}
+	b.WriteString(")\n(:init\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "\t(package pkg%d) (location loc%d)\n", i, i)
+		fmt.Fprintf(&b, "\t(at pkg%d loc%d)\n", i, (i*7)%n)
+		for j := 1; j <= 4; j++ {
+			fmt.Fprintf(&b, "\t(road loc%d loc%d)\n", i, (i+j)%n)
+		}
+	}
+	b.WriteString(")\n(:goal (and")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, " (at pkg%d loc%d)", i, i)
+	}
+	b.WriteString(")))\n")
+	return b.String()
+}
+
+func TestLargeProblem(t *testing.T) {
+	const n = 1000
+	ast, err := Parse("", strings.NewReader(largeProblem(n)))
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	}
+	p := ast.(*Problem)
+	if len(p.Init) != 7*n {
+		t.Fatalf("expected %d init facts, got %d", 7*n, len(p.Init))
+	}
+	lineOffs := []int{0, 0, 1, 2, 3, 4, 5}
+	for i, f := range p.Init {
+		want := 4 + 6*(i/7) + lineOffs[i%7]
+		if l := f.(*LiteralNode).Location.Line; l != want {
+			t.Fatalf("expected init fact %d on line %d, got %d", i, want, l)
+		}
+	}
+	for i := 0; i < n; i++ {
+		lit := p.Init[7*i+2].(*LiteralNode)
+		want := fmt.Sprintf("(at pkg%d loc%d)", i, (i*7)%n)
+		if s := literalString(lit); s != want {
+			t.Errorf("expected %s, got %s", want, s)
+		}
+		for j := 1; j <= 4; j++ {
+			lit := p.Init[7*i+2+j].(*LiteralNode)
+			want := fmt.Sprintf("(road loc%d loc%d)", i, (i+j)%n)
+			if s := literalString(lit); s != want {
+				t.Errorf("expected %s, got %s", want, s)
+			}
+		}
+	}
+}
+
+func BenchmarkParseLargeProblem(b *testing.B) {
+	text := largeProblem(10000)
+	b.SetBytes(int64(len(text)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse("", strings.NewReader(text)); err != nil {
+			b.Fatalf("parse error: %s", err)
+		}
+	}
+}
+
+func BenchmarkLexLargeProblem(b *testing.B) {
+	text := largeProblem(10000)
+	b.SetBytes(int64(len(text)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l := newLexer("", strings.NewReader(text))
+		for l.token().typ != tokEof {
+		}
+	}
+}
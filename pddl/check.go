@@ -4,6 +4,7 @@ package pddl
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -26,17 +27,19 @@ func Check(d *Domain, p *Problem) []error {
 	if p == nil {
 		return errs
 	}
-	if p.Domain.Str != d.Str {
-		errs.errorf("problem %s expects domain %s, but got %s",
-			p.Name, p.Domain, d.Name)
+	if p.Domain.Key() != d.Key() {
+		errs = append(errs, DomainMismatchError{
+			Location: p.Domain.Location,
+			Problem:  p.Name,
+			Expected: p.Domain,
+			Domain:   d.Name,
+		})
 	}
 	checkReqsDef(defs, p.Requirements, &errs)
 	checkConstsDef(defs, p.Objects, &errs)
-	for i := range p.Init {
-		p.Init[i].check(defs, &errs)
-	}
+	checkInit(defs, p.Init, &errs)
 	p.Goal.check(defs, &errs)
-	// check the metric
+	checkMetric(defs, p, &errs)
 	return errs
 }
 
@@ -50,13 +53,17 @@ type (
 		preds  map[string]*Predicate
 		funcs  map[string]*Function
 		vars   *varDefs
+
+		// process is true while checking a PDDL+ process,
+		// the only place where continuous effects are allowed.
+		process bool
 	}
 
 	// varDefs implements a stack of variable
 	// definitions.
 	varDefs struct {
 		up         *varDefs
-		name       string
+		key        string
 		definition *TypedEntry
 	}
 )
@@ -77,6 +84,22 @@ func checkDomain(d *Domain, errs *errors) defs {
 	for i := range d.Actions {
 		checkActionDef(defs, &d.Actions[i], errs)
 	}
+	for i := range d.Processes {
+		proc := &d.Processes[i]
+		if !defs.reqs[":time"] {
+			errs.badReq(proc, ":process", ":time")
+		}
+		pdefs := defs
+		pdefs.process = true
+		checkActionDef(pdefs, proc, errs)
+	}
+	for i := range d.Events {
+		ev := &d.Events[i]
+		if !defs.reqs[":time"] {
+			errs.badReq(ev, ":event", ":time")
+		}
+		checkActionDef(defs, ev, errs)
+	}
 	return defs
 }
 
@@ -96,18 +119,22 @@ var (
 		":existential-preconditions": true,
 		":conditional-effects":       true,
 		":action-costs":              true,
+		":object-fluents":            true,
+		":timed-initial-literals":    true,
+		":time":                      true,
+		":probabilistic-effects":     true,
 	}
 )
 
 func checkReqsDef(defs defs, rs []Name, errs *errors) {
 	for _, r := range rs {
-		req := strings.ToLower(r.Str)
+		req := r.Key()
 		if !supportedReqs[req] {
 			errs.add(r, "requirement %s is not supported", r)
 			continue
 		}
 		if defs.reqs[req] {
-			errs.multipleDefs(r, "requirement")
+			errs.multipleDefs(r, Name{}, "requirement")
 		}
 		defs.reqs[req] = true
 	}
@@ -129,12 +156,21 @@ func checkReqsDef(defs defs, rs []Name, errs *errors) {
 // CheckTypesDef checks a list of type definitions, maps type names to their definitions, and
 // builds the list of all super types of each type.  If the implicit object type was not defined
 // then  it is added.
+//
+// A type declared with an either super type, as in (:types u - (either s t)), is a sub type
+// of each of the listed types.
 func checkTypesDef(defs defs, d *Domain, errs *errors) {
-	if len(d.Types) > 0 && !defs.reqs[":typing"] {
+	// The implicit object type has no location, and
+	// it is left in the types by a previous check.
+	if len(d.Types) > 0 && d.Types[0].Location.Line != 0 && !defs.reqs[":typing"] {
 		errs.badReq(d.Types[0], ":types", ":typing")
 	}
+	// Objects are added to the type domains by each check.
+	for i := range d.Types {
+		d.Types[i].Domain = nil
+	}
 	// Ensure that object is defined
-	if !objectDefined(d.Types) {
+	if !objectDefined(defs, d.Types) {
 		d.Types = append(d.Types, Type{
 			TypedEntry: TypedEntry{
 				Name: Name{Str: objectTypeName},
@@ -144,22 +180,19 @@ func checkTypesDef(defs defs, d *Domain, errs *errors) {
 
 	// Map type names to their definitions
 	for i, t := range d.Types {
-		if len(t.Types) > 1 {
-			errs.add(t, "either super types are not semantically defined")
-			continue
-		}
-		if defs.types[strings.ToLower(t.Str)] != nil {
-			errs.multipleDefs(t.Name, "type")
+		if prev := defs.types[t.Key()]; prev != nil {
+			errs.multipleDefs(t.Name, prev.Name, "type")
 			continue
 		}
 		d.Types[i].Num = len(defs.types)
-		defs.types[strings.ToLower(t.Str)] = &d.Types[i]
+		defs.types[t.Key()] = &d.Types[i]
 	}
 
 	// Link parent types to their definitions
 	for i := range d.Types {
 		checkTypeNames(defs, d.Types[i].Types, errs)
 	}
+	checkTypeCycles(defs, d.Types, errs)
 
 	// Build super type lists
 	for i := range d.Types {
@@ -170,10 +203,54 @@ func checkTypesDef(defs defs, d *Domain, errs *errors) {
 	}
 }
 
+// CheckTypeCycles adds an error for each cycle in the type hierarchy.
+// Types with no declared super type are implicitly sub types of object.
+func checkTypeCycles(defs defs, types []Type, errs *errors) {
+	obj := defs.types[objectTypeName]
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*Type]int, len(types))
+	var path []*Type
+	var visit func(*Type)
+	visit = func(t *Type) {
+		state[t] = visiting
+		path = append(path, t)
+		supers := t.Types
+		if len(supers) == 0 && t != obj {
+			supers = []TypeName{{Name: t.Name, Definition: obj}}
+		}
+		for _, s := range supers {
+			switch u := s.Definition; {
+			case u == nil:
+				continue
+			case state[u] == visiting:
+				var names []string
+				for i := len(path) - 1; i >= 0 && path[i] != u; i-- {
+					names = append([]string{path[i].Str}, names...)
+				}
+				names = append(append([]string{u.Str}, names...), u.Str)
+				errs.add(s, "type %s is its own super type: %s", u, strings.Join(names, " - "))
+			case state[u] == unvisited:
+				visit(u)
+			}
+		}
+		path = path[:len(path)-1]
+		state[t] = visited
+	}
+	for i := range types {
+		if state[&types[i]] == unvisited {
+			visit(&types[i])
+		}
+	}
+}
+
 // ObjectDefined returns true if the object type is in the list of defined types.
-func objectDefined(ts []Type) bool {
+func objectDefined(defs defs, ts []Type) bool {
 	for _, t := range ts {
-		if t.Str == objectTypeName {
+		if t.Key() == objectTypeName {
 			return true
 		}
 	}
@@ -205,14 +282,20 @@ func superTypes(defs defs, t *Type) (supers []*Type) {
 }
 
 // CheckConstsDef checks a list of constant or object definitions and maps names to their definitions.
+// Objects may not shadow constants that were defined by an earlier list.
 func checkConstsDef(defs defs, objs []TypedEntry, errs *errors) {
+	nprev := len(defs.consts)
 	for i, obj := range objs {
-		if defs.consts[strings.ToLower(obj.Str)] != nil {
-			errs.multipleDefs(obj.Name, "object")
+		if def := defs.consts[obj.Key()]; def != nil {
+			if def.Num < nprev {
+				errs.add(obj, "object %s shadows constant %s", obj, def)
+			} else {
+				errs.multipleDefs(obj.Name, def.Name, "object")
+			}
 			continue
 		}
 		objs[i].Num = len(defs.consts)
-		defs.consts[strings.ToLower(obj.Str)] = &objs[i]
+		defs.consts[obj.Key()] = &objs[i]
 	}
 	checkTypedEntries(defs, objs, errs)
 
@@ -241,79 +324,187 @@ func (t *Type) addToDomain(obj *TypedEntry) {
 	t.Domain = append(t.Domain, obj)
 }
 
-// CheckPredsDef checks a list of predicate definitions and maps their names to their definitions.
-// If :equality is required and the implicit = predicate was not defined then it is added.
-func checkPredsDef(defs defs, d *Domain, errs *errors) {
-	if defs.reqs[":equality"] && !equalDefined(d.Predicates) {
-		d.Predicates = append(d.Predicates, Predicate{
-			Name: Name{Str: "="},
-			Num:  len(defs.preds),
-			Parameters: []TypedEntry{
-				{Name: Name{Str: "?x"}},
-				{Name: Name{Str: "?y"}},
-			},
-		})
-	}
-	for i, p := range d.Predicates {
-		if defs.preds[strings.ToLower(p.Str)] != nil {
-			errs.multipleDefs(p.Name, "predicate")
+// CheckInit checks the :init section of a problem.  Each literal must be
+// positive and ground, each function must be assigned at most once, and
+// total-cost must be assigned 0.  Timed initial literals may be negative,
+// and timed initial fluents may assign functions that are already assigned.
+func checkInit(defs defs, init []Formula, errs *errors) {
+	assigned := make(map[string]bool)
+	for _, f := range init {
+		switch n := f.(type) {
+		case *TimedNode:
+			if !groundInit(n.Formula, errs) {
+				continue
+			}
+		case *LiteralNode:
+			if n.Negative {
+				errs.add(n, "negative literal in :init")
+			}
+			if !ground(n.Arguments, errs) {
+				continue
+			}
+		case *EqualNode:
+			errs.add(n, "equality in :init")
 			continue
-		}
-		checkTypedEntries(defs, p.Parameters, errs)
-		counts := make(map[string]int, len(p.Parameters))
-		for _, parm := range p.Parameters {
-			if counts[parm.Str] > 0 {
-				errs.multipleDefs(parm.Name, "parameter")
+		case *AssignNode:
+			if !groundAssign(n, errs) {
+				continue
+			}
+			key := n.Lval.Key()
+			for _, a := range n.Lval.Arguments {
+				key += " " + a.Key()
 			}
-			counts[parm.Str]++
+			if assigned[key] {
+				errs.add(n.Lval, "function %s assigned multiple times in :init", n.Lval.String())
+			}
+			assigned[key] = true
+		}
+		f.check(defs, errs)
+		if a, ok := f.(*AssignNode); ok && a.Lval.Definition != nil && a.Lval.Definition.isTotalCost() &&
+			defs.reqs[":action-costs"] && !zero(a.Number) {
+			errs.add(a, "total-cost must be initialized to 0 with :action-costs")
 		}
-		d.Predicates[i].Num = len(defs.preds)
-		defs.preds[strings.ToLower(p.Str)] = &d.Predicates[i]
 	}
 }
 
-// EqualDefined returns true if the = predicate is in the list of defined predicates.
-func equalDefined(ps []Predicate) bool {
-	for _, p := range ps {
-		if p.Str == "=" {
-			return true
+// GroundInit returns true if the literal or the assignment of
+// a timed initial literal or fluent is ground.
+func groundInit(f Formula, errs *errors) bool {
+	switch n := f.(type) {
+	case *LiteralNode:
+		return ground(n.Arguments, errs)
+	case *AssignNode:
+		return groundAssign(n, errs)
+	}
+	return true
+}
+
+func (n *TimedNode) check(defs defs, errs *errors) {
+	if !defs.reqs[":timed-initial-literals"] {
+		errs.badReq(n, "at", ":timed-initial-literals")
+	}
+	if negative(n.Time) {
+		errs.add(n, "timed initial literal at negative time %s", n.Time)
+	}
+	if e, ok := n.Formula.(*EqualNode); ok {
+		errs.add(e, "equality in :init")
+		return
+	}
+	n.Formula.check(defs, errs)
+}
+
+// Ground returns true if none of the terms are variables or function terms.
+// An error is added for each variable and function term.
+func ground(terms []Term, errs *errors) bool {
+	g := true
+	for _, t := range terms {
+		switch {
+		case t.Variable:
+			errs.add(t, "variable %s in :init", t)
+			g = false
+		case t.Fhead != nil:
+			errs.add(t, "function term %s in :init", t)
+			g = false
 		}
 	}
-	return false
+	return g
+}
+
+// GroundAssign returns true if an :init assignment is ground and
+// its value is a number or a constant.  An error is added for each
+// variable and function term, and for a function value.
+func groundAssign(a *AssignNode, errs *errors) bool {
+	g := ground(a.Lval.Arguments, errs)
+	switch {
+	case a.IsTerm:
+		g = ground([]Term{a.Term}, errs) && g
+	case !a.IsNumber:
+		errs.add(a, "function value %s in :init", a.Fhead)
+		g = false
+	}
+	return g
+}
+
+// Zero returns true if the string is a number equal to zero.
+func zero(n string) bool {
+	f, err := strconv.ParseFloat(strings.TrimLeft(n, "-"), 64)
+	return err == nil && f == 0
+}
+
+// CheckMetric checks the problem metric.  Minimizing total-cost requires
+// :action-costs and a 0-ary total-cost function.
+func checkMetric(defs defs, p *Problem, errs *errors) {
+	if p.Metric != MetricMinCost {
+		return
+	}
+	if !defs.reqs[":action-costs"] {
+		errs.badReq(p, ":metric", ":action-costs")
+	}
+	if f := defs.funcs[totalCostName]; f == nil || !f.isTotalCost() {
+		errs.add(p, "metric requires a 0-ary %s function", totalCostName)
+	}
+}
+
+// CheckPredsDef checks a list of predicate definitions and maps their names to their definitions.
+func checkPredsDef(defs defs, d *Domain, errs *errors) {
+	for i, p := range d.Predicates {
+		if prev := defs.preds[p.Key()]; prev != nil {
+			errs.multipleDefs(p.Name, prev.Name, "predicate")
+			continue
+		}
+		checkTypedEntries(defs, p.Parameters, errs)
+		checkUnique(defs, p.Parameters, "parameter", errs)
+		d.Predicates[i].Num = len(defs.preds)
+		defs.preds[p.Key()] = &d.Predicates[i]
+	}
 }
 
 // CheckFuncsDef checks a list of function definitions and maps their names to their definitions.
+// Numeric functions require :action-costs or :time, and functions
+// with object types require :object-fluents.
+//
+// A missing requirement is reported once for the list.
 func checkFuncsDef(defs defs, fs []Function, errs *errors) {
-	if len(fs) > 0 && !defs.reqs[":action-costs"] {
-		errs.badReq(fs[0], ":functions", ":action-costs")
-	}
+	costsReported, fluentsReported := false, false
 	for i, f := range fs {
-		if defs.funcs[strings.ToLower(f.Str)] != nil {
-			errs.multipleDefs(f.Name, "function")
-			continue
+		if numeric(&f) && !defs.reqs[":action-costs"] && !defs.reqs[":time"] && !costsReported {
+			errs.badReq(f, ":functions", ":action-costs")
+			costsReported = true
 		}
-		checkTypedEntries(defs, f.Parameters, errs)
-		counts := make(map[string]int, len(f.Parameters))
-		for _, parm := range f.Parameters {
-			if counts[parm.Str] > 0 {
-				errs.multipleDefs(parm.Name, "parameter")
+		if !numeric(&f) {
+			if !defs.reqs[":object-fluents"] && !fluentsReported {
+				errs.badReq(f, "object fluent", ":object-fluents")
+				fluentsReported = true
 			}
-			counts[parm.Str]++
+			checkTypeNames(defs, fs[i].Types, errs)
+		}
+		if prev := defs.funcs[f.Key()]; prev != nil {
+			errs.multipleDefs(f.Name, prev.Name, "function")
+			continue
 		}
+		checkTypedEntries(defs, f.Parameters, errs)
+		checkUnique(defs, f.Parameters, "parameter", errs)
 		fs[i].Num = len(defs.funcs)
-		defs.funcs[strings.ToLower(f.Str)] = &fs[i]
+		defs.funcs[f.Key()] = &fs[i]
 	}
 }
 
+// Numeric returns true if the function evaluates to a number.
+// Functions with no type are numeric.
+func numeric(f *Function) bool {
+	for _, t := range f.Types {
+		if t.Key() != "number" {
+			return false
+		}
+	}
+	return true
+}
+
 func checkActionDef(defs defs, act *Action, errs *errors) {
 	checkTypedEntries(defs, act.Parameters, errs)
-	counts := make(map[string]int, len(act.Parameters))
-	for i, parm := range act.Parameters {
-		if counts[parm.Str] > 0 {
-			errs.multipleDefs(parm.Name, "parameter")
-		}
-		counts[parm.Str]++
-		defs.vars = defs.vars.push(&act.Parameters[i])
+	checkUnique(defs, act.Parameters, "parameter", errs)
+	for i := range act.Parameters {
+		defs.vars = defs.vars.push(act.Parameters[i].Key(), &act.Parameters[i])
 	}
 	if act.Precondition != nil {
 		act.Precondition.check(defs, errs)
@@ -326,11 +517,26 @@ func checkActionDef(defs defs, act *Action, errs *errors) {
 	}
 }
 
-// Push returns a new varDefs with the given definitions defined.
-func (v *varDefs) push(d *TypedEntry) *varDefs {
+// CheckUnique adds an error for each entry of a typed list
+// that has the same name as an earlier entry.
+func checkUnique(defs defs, lst []TypedEntry, kind string, errs *errors) {
+	seen := make(map[string]Name, len(lst))
+	for _, e := range lst {
+		key := e.Key()
+		if prev, ok := seen[key]; ok {
+			errs.multipleDefs(e.Name, prev, kind)
+			continue
+		}
+		seen[key] = e.Name
+	}
+}
+
+// Push returns a new varDefs with the given definition
+// defined for the given canonical key.
+func (v *varDefs) push(key string, d *TypedEntry) *varDefs {
 	return &varDefs{
 		up:         v,
-		name:       d.Str,
+		key:        key,
 		definition: d,
 	}
 }
@@ -352,12 +558,16 @@ func checkTypedEntries(defs defs, lst []TypedEntry, errs *errors) {
 
 // CheckTypeNames checks that all of the type names are defined.  Each defined type name
 // is linked to its type definition.
+//
+// The implicit object type given to untyped entries by a previous check has
+// no location, and it does not require :typing, so that Check can be called
+// more than once on the same domain.
 func checkTypeNames(defs defs, ts []TypeName, errs *errors) {
-	if len(ts) > 0 && !defs.reqs[":typing"] {
+	if len(ts) > 0 && ts[0].Location.Line != 0 && !defs.reqs[":typing"] {
 		errs.badReq(ts[0], "types", ":typing")
 	}
 	for j, t := range ts {
-		switch def := defs.types[strings.ToLower(t.Str)]; def {
+		switch def := defs.types[t.Key()]; def {
 		case nil:
 			errs.undefined(t.Name, "type")
 		default:
@@ -383,13 +593,9 @@ func (m *MultiNode) check(defs defs, errs *errors) {
 
 func (q *QuantNode) check(defs defs, errs *errors) {
 	checkTypedEntries(defs, q.Variables, errs)
-	counts := make(map[string]int, len(q.Variables))
-	for i, v := range q.Variables {
-		if counts[v.Str] > 0 {
-			errs.multipleDefs(v.Name, "variable")
-		}
-		counts[v.Str]++
-		defs.vars = defs.vars.push(&q.Variables[i])
+	checkUnique(defs, q.Variables, "variable", errs)
+	for i := range q.Variables {
+		defs.vars = defs.vars.push(q.Variables[i].Key(), &q.Variables[i])
 	}
 	q.UnaryNode.check(defs, errs)
 	for _ = range q.Variables {
@@ -410,7 +616,7 @@ func (n *OrNode) check(defs defs, errs *errors) {
 }
 
 func (n *NotNode) check(defs defs, errs *errors) {
-	switch _, ok := n.Formula.(*LiteralNode); {
+	switch ok := isAtom(n.Formula); {
 	case ok && !defs.reqs[":negative-preconditions"]:
 		errs.badReq(n, "negative literal", ":negative-preconditions")
 	case !ok && !defs.reqs[":disjunctive-preconditions"]:
@@ -419,6 +625,15 @@ func (n *NotNode) check(defs defs, errs *errors) {
 	n.UnaryNode.check(defs, errs)
 }
 
+// IsAtom returns true if the formula is a literal or an equality.
+func isAtom(f Formula) bool {
+	switch f.(type) {
+	case *LiteralNode, *EqualNode:
+		return true
+	}
+	return false
+}
+
 func (i *ImplyNode) check(defs defs, errs *errors) {
 	if !defs.reqs[":disjunctive-preconditions"] {
 		errs.badReq(i, "imply", ":disjunctive-preconditions")
@@ -451,11 +666,37 @@ func (w *WhenNode) check(defs defs, errs *errors) {
 	w.UnaryNode.check(defs, errs)
 }
 
+// Check checks that the probabilities of the outcomes
+// are in [0,1] and that they sum to at most 1.
+func (n *ProbabilisticNode) check(defs defs, errs *errors) {
+	if !defs.reqs[":probabilistic-effects"] {
+		errs.badReq(n, "probabilistic", ":probabilistic-effects")
+	}
+	sum := 0.0
+	for _, s := range n.Probabilities {
+		pr, err := strconv.ParseFloat(s, 64)
+		if err != nil || pr < 0 || pr > 1 {
+			errs.add(n, "probability %s is not in [0,1]", s)
+			continue
+		}
+		sum += pr
+	}
+	// Allow for rounding, as in 0.1 0.2 0.7.
+	const epsilon = 1e-9
+	if sum > 1+epsilon {
+		errs.add(n, "probabilities sum to %g, more than 1", sum)
+	}
+	n.MultiNode.check(defs, errs)
+}
+
 func (lit *LiteralNode) check(defs defs, errs *errors) {
-	if lit.Definition = defs.preds[strings.ToLower(lit.Predicate.Str)]; lit.Definition == nil {
+	if lit.Definition = defs.preds[lit.Predicate.Key()]; lit.Definition == nil {
 		errs.undefined(lit.Predicate, "predicate")
 		return
 	}
+	if lit.IsEffect && defs.process {
+		errs.add(lit, "process effects must be continuous")
+	}
 	if lit.IsEffect {
 		if lit.Negative {
 			lit.Definition.NegEffect = true
@@ -469,42 +710,128 @@ func (lit *LiteralNode) check(defs defs, errs *errors) {
 // CheckInst checks the arguments match the parameters of a predicate or function instantiation.
 func checkInst(defs defs, n Name, args []Term, parms []TypedEntry, errs *errors) {
 	if len(args) != len(parms) {
-		var argStr = "arguments"
-		if len(parms) == 1 {
-			argStr = argStr[:len(argStr)-1]
-		}
-		errs.add(n, "%s requires %d %s", n, len(parms), argStr)
+		*errs = append(*errs, ArityError{
+			Location:   n.Location,
+			Name:       n,
+			Parameters: parms,
+			Arguments:  args,
+		})
 	}
 
 	for i := range args {
-		kind := "constant"
-		args[i].Definition = defs.consts[strings.ToLower(args[i].Str)]
-		if args[i].Variable {
-			args[i].Definition = defs.vars.find(args[i].Str)
-			kind = "variable"
-		}
-		if args[i].Definition == nil {
-			errs.undefined(args[i].Name, kind)
+		if !checkTerm(defs, &args[i], errs) {
 			return
 		}
-		if !compatTypes(parms[i].Types, args[i].Definition.Types) {
-			errs.add(args[i],
-				"%s [type %s] is incompatible with parameter %s [type %s] of %s",
-				args[i], typeString(args[i].Definition.Types),
-				parms[i], typeString(parms[i].Types), n)
+		if i < len(parms) && !compatTypes(parms[i].Types, termTypes(args[i])) {
+			*errs = append(*errs, TypeMismatchError{
+				Location:  args[i].Location,
+				Name:      n,
+				Argument:  args[i],
+				Parameter: parms[i],
+			})
 		}
 	}
 }
 
-// Find returns the definition of the variable or nil if it was not defined.
-func (v *varDefs) find(n string) *TypedEntry {
+// CheckTerm links a term to the definition of its variable or constant.  If the
+// term is not defined then an error is added and false is returned.
+//
+// A function term must be an application of an object fluent.
+func checkTerm(defs defs, t *Term, errs *errors) bool {
+	if t.Fhead != nil {
+		return checkFunctionTerm(defs, t, errs)
+	}
+	kind := "constant"
+	t.Definition = defs.consts[t.Key()]
+	if t.Variable {
+		t.Definition = defs.vars.find(t.Key())
+		kind = "variable"
+	}
+	if t.Definition == nil {
+		errs.undefined(t.Name, kind)
+		return false
+	}
+	return true
+}
+
+// CheckFunctionTerm checks the application of an object fluent as a term.
+func checkFunctionTerm(defs defs, t *Term, errs *errors) bool {
+	if !defs.reqs[":object-fluents"] {
+		errs.badReq(t, "function term", ":object-fluents")
+	}
+	t.Fhead.check(defs, errs)
+	switch f := t.Fhead.Definition; {
+	case f == nil:
+		return false
+	case numeric(f):
+		errs.add(t, "numeric function %s used as a term", t)
+		return false
+	}
+	return true
+}
+
+// TermTypes returns the types of a term that has been linked to
+// its definition.  The types of a function term are the types
+// of its function.
+func termTypes(t Term) []TypeName {
+	if t.Fhead != nil {
+		return t.Fhead.Definition.Types
+	}
+	return t.Definition.Types
+}
+
+func (e *EqualNode) check(defs defs, errs *errors) {
+	if e.IsEffect {
+		errs.add(e, "equality is not allowed in effects")
+		return
+	}
+	if !defs.reqs[":equality"] {
+		errs.badReq(e, "=", ":equality")
+	}
+	checkTerm(defs, &e.Left, errs)
+	checkTerm(defs, &e.Right, errs)
+}
+
+// Find returns the definition of the variable with the
+// given canonical key, or nil if it was not defined.
+func (v *varDefs) find(key string) *TypedEntry {
 	if v == nil {
 		return nil
 	}
-	if strings.ToLower(v.name) == strings.ToLower(n) {
+	if v.key == key {
 		return v.definition
 	}
-	return v.up.find(n)
+	return v.up.find(key)
+}
+
+// DisjointTypes returns true if no object can be of both the left and right types.
+// Types are not disjoint if one is a super type of the other, or if they have a
+// common sub type among the given types.
+func disjointTypes(types []Type, left, right []TypeName) bool {
+	for _, l := range left {
+		for _, r := range right {
+			if l.Definition == nil || r.Definition == nil {
+				// undefined, don't report a new error.
+				return false
+			}
+			for i := range types {
+				if isSuper(l.Definition, &types[i]) && isSuper(r.Definition, &types[i]) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// IsSuper returns true if s is a super type of t or if s is t.
+func isSuper(s, t *Type) bool {
+	for _, u := range t.Supers {
+		if u == s {
+			return true
+		}
+	}
+	return false
 }
 
 // GompatTypes returns true if each type on the right is convertable to each type on the left.
@@ -538,19 +865,40 @@ func compatTypes(left, right []TypeName) bool {
 }
 
 func (a *AssignNode) check(defs defs, errs *errors) {
-	if !defs.reqs[":action-costs"] {
+	if f := defs.funcs[a.Lval.Key()]; f != nil && !numeric(f) {
+		a.Lval.check(defs, errs)
+		a.checkObject(defs, errs)
+		return
+	}
+	if a.IsTerm && !a.Term.Variable {
+		// A bare name assigned to a numeric function
+		// is the application of a 0-ary function.
+		a.IsTerm = false
+		a.Fhead = Fhead{Name: a.Term.Name}
+	}
+	switch op := a.Op.Key(); {
+	case timeOps[op]:
+		if !defs.reqs[":time"] {
+			errs.badReq(a, a.Op.Str, ":time")
+		}
+	case !defs.reqs[":action-costs"] && !defs.reqs[":time"]:
 		errs.badReq(a, a.Op.Str, ":action-costs")
 	}
 	a.Lval.check(defs, errs)
-	if a.IsNumber {
-		if negative(a.Number) {
+	a.checkContinuous(defs, errs)
+	switch {
+	case a.IsTerm:
+		errs.add(a.Term, "%s assigned to numeric function %s", a.Term, a.Lval.Str)
+	case a.IsNumber:
+		if negative(a.Number) && !defs.reqs[":time"] {
 			errs.add(a, "assigned value must not be negative with :action-costs")
 		}
-	} else {
+	default:
 		a.Fhead.check(defs, errs)
 	}
 
-	if !a.IsInit {
+	// With :time, any numeric function may be assigned any value.
+	if !a.IsInit && !defs.reqs[":time"] {
 		if a.Lval.Definition != nil && !a.Lval.Definition.isTotalCost() {
 			errs.add(a.Lval, "assignment target must be a 0-ary total-cost function with :action-costs")
 		}
@@ -560,8 +908,52 @@ func (a *AssignNode) check(defs defs, errs *errors) {
 	}
 }
 
+// CheckContinuous checks that continuous effects only increase or
+// decrease a function in a process, and that processes only have
+// continuous effects.
+func (a *AssignNode) checkContinuous(defs defs, errs *errors) {
+	switch op := a.Op.Key(); {
+	case a.Continuous && !defs.process:
+		errs.add(a, "continuous effect outside of a process")
+	case a.Continuous && op != "increase" && op != "decrease":
+		errs.add(a, "%s is not allowed in a continuous effect", a.Op)
+	case !a.Continuous && defs.process:
+		errs.add(a, "process effects must be continuous")
+	}
+}
+
+// CheckObject checks the assignment of a value to an object fluent.  The value
+// must be a term or an object fluent with a type compatible with the fluent.
+func (a *AssignNode) checkObject(defs defs, errs *errors) {
+	if !a.IsInit && a.Op.Key() != "assign" {
+		errs.add(a, "%s is not allowed on object fluent %s", a.Op, a.Lval.Str)
+	}
+	var t Term
+	switch {
+	case a.IsNumber:
+		errs.add(a, "number %s assigned to object fluent %s", a.Number, a.Lval.Str)
+		return
+	case a.IsTerm:
+		t = a.Term
+		if !checkTerm(defs, &t, errs) {
+			return
+		}
+		a.Term = t
+	default:
+		t = Term{Name: a.Fhead.Name, Fhead: &a.Fhead}
+		if !checkFunctionTerm(defs, &t, errs) {
+			return
+		}
+	}
+	if !compatTypes(a.Lval.Definition.Types, termTypes(t)) {
+		errs.add(t, "%s [type %s] is incompatible with object fluent %s [type %s]",
+			t, typeString(termTypes(t)), a.Lval.Str, typeString(a.Lval.Definition.Types))
+	}
+}
+
+// IsTotalCost returns true if the function is a 0-ary total-cost function.
 func (f *Function) isTotalCost() bool {
-	return f.Str == totalCostName && len(f.Parameters) == 0
+	return f.Key() == totalCostName && len(f.Parameters) == 0
 }
 
 // Negative returns true if the string is a negative number.
@@ -577,8 +969,8 @@ func negative(n string) bool {
 }
 
 func (h *Fhead) check(defs defs, errs *errors) {
-	if h.Definition = defs.funcs[strings.ToLower(h.Str)]; h.Definition == nil {
-		errs.undefined(h.Name, " function")
+	if h.Definition = defs.funcs[h.Key()]; h.Definition == nil {
+		errs.undefined(h.Name, "function")
 		return
 	}
 	checkInst(defs, h.Name, h.Arguments, h.Definition.Parameters, errs)
@@ -592,19 +984,23 @@ func (es *errors) add(l Locer, f string, vs ...interface{}) {
 	*es = append(*es, Error{l.Loc(), fmt.Sprintf(f, vs...)})
 }
 
-// Errorf adds an error.
-func (es *errors) errorf(f string, vs ...interface{}) {
-	*es = append(*es, fmt.Errorf(f, vs...))
-}
-
 // Undefined adds an undefined error.
 func (es *errors) undefined(name Name, kind string) {
-	es.add(name, "undefined %s %s", kind, name.Str)
+	*es = append(*es, UndefinedError{
+		Location: name.Location,
+		Kind:     kind,
+		Name:     name,
+	})
 }
 
 // MultipleDefs adds a multiply defined error.
-func (es *errors) multipleDefs(name Name, kind string) {
-	es.add(name, "%s %s defined multiple times", kind, name.Str)
+func (es *errors) multipleDefs(name, prev Name, kind string) {
+	*es = append(*es, RedefinitionError{
+		Location: name.Location,
+		Kind:     kind,
+		Name:     name,
+		Previous: prev,
+	})
 }
 
 // BadReq adds a missing requirement error to the slice.
@@ -631,3 +1027,157 @@ type MissingRequirementError struct {
 func (r MissingRequirementError) Error() string {
 	return r.Loc().String() + ": " + r.Cause + " requires " + r.Requirement
 }
+
+// Code returns CodeMissingRequirement.
+func (r MissingRequirementError) Code() string {
+	return CodeMissingRequirement
+}
+
+// UndefinedError is used when a name is referenced but not defined.
+type UndefinedError struct {
+	// Location is the location of the reference.
+	Location
+
+	// Kind is the kind of entity that the name is referring to,
+	// such as type, constant, variable, predicate, or function.
+	Kind string
+
+	// Name is the undefined name.
+	Name Name
+}
+
+func (u UndefinedError) Error() string {
+	return u.Loc().String() + ": undefined " + u.Kind + " " + u.Name.Str
+}
+
+// Code returns CodeUndefined.
+func (u UndefinedError) Code() string {
+	return CodeUndefined
+}
+
+// RedefinitionError is used when a name is defined multiple times.
+type RedefinitionError struct {
+	// Location is the location of the redefinition.
+	Location
+
+	// Kind is the kind of entity that is redefined, such as
+	// requirement, type, object, predicate, function, parameter,
+	// or variable.
+	Kind string
+
+	// Name is the name of the redefinition.
+	Name Name
+
+	// Previous is the name of the previous definition.
+	// It is the zero Name for requirements.
+	Previous Name
+}
+
+func (r RedefinitionError) Error() string {
+	return r.Loc().String() + ": " + r.Kind + " " + r.Name.Str + " defined multiple times"
+}
+
+// Code returns CodeRedefinition.
+func (r RedefinitionError) Code() string {
+	return CodeRedefinition
+}
+
+// ArityError is used when a predicate or function is instantiated
+// with the wrong number of arguments.
+type ArityError struct {
+	// Location is the location of the instantiation.
+	Location
+
+	// Name is the name of the instantiated predicate or function.
+	Name Name
+
+	// Parameters are the parameters of the definition.
+	Parameters []TypedEntry
+
+	// Arguments are the arguments of the instantiation.
+	Arguments []Term
+}
+
+func (a ArityError) Error() string {
+	argStr := "arguments"
+	if len(a.Parameters) == 1 {
+		argStr = argStr[:len(argStr)-1]
+	}
+	return fmt.Sprintf("%s: %s requires %d %s", a.Loc(), a.Name, len(a.Parameters), argStr)
+}
+
+// Code returns CodeArity.
+func (a ArityError) Code() string {
+	return CodeArity
+}
+
+// TypeMismatchError is used when the type of an argument is
+// incompatible with the type of its parameter.
+type TypeMismatchError struct {
+	// Location is the location of the argument.
+	Location
+
+	// Name is the name of the instantiated predicate or function.
+	Name Name
+
+	// Argument is the argument, linked to its definition.
+	Argument Term
+
+	// Parameter is the parameter definition.
+	Parameter TypedEntry
+}
+
+func (t TypeMismatchError) Error() string {
+	return fmt.Sprintf("%s: %s [type %s] is incompatible with parameter %s [type %s] of %s",
+		t.Loc(), t.Argument, typeString(termTypes(t.Argument)),
+		t.Parameter, typeString(t.Parameter.Types), t.Name)
+}
+
+// Code returns CodeTypeMismatch.
+func (t TypeMismatchError) Code() string {
+	return CodeTypeMismatch
+}
+
+// DomainMismatchError is used when a problem is checked
+// against a domain other than the one that it names.
+type DomainMismatchError struct {
+	// Location is the location of the problem's domain name.
+	Location
+
+	// Problem is the name of the problem.
+	Problem Name
+
+	// Expected is the domain name given by the problem.
+	Expected Name
+
+	// Domain is the name of the domain against which
+	// the problem was checked.
+	Domain Name
+}
+
+func (d DomainMismatchError) Error() string {
+	return fmt.Sprintf("%s: problem %s expects domain %s, but got %s",
+		d.Loc(), d.Problem, d.Expected, d.Domain)
+}
+
+// Code returns CodeDomainMismatch.
+func (d DomainMismatchError) Code() string {
+	return CodeDomainMismatch
+}
+
+// A Coder is an error with a stable error code.
+type Coder interface {
+	Code() string
+}
+
+// The error codes of the exported error types.  The codes
+// are stable, and can be used to identify kinds of errors
+// in tools and configuration.
+const (
+	CodeMissingRequirement = "missing-requirement"
+	CodeUndefined          = "undefined"
+	CodeRedefinition       = "redefinition"
+	CodeArity              = "arity"
+	CodeTypeMismatch       = "type-mismatch"
+	CodeDomainMismatch     = "domain-mismatch"
+)
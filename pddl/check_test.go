@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"unicode"
 )
 
 var reqsDefTests = []checkDomainTest{
@@ -74,12 +75,37 @@ var requirementsTests = []checkDomainTest{
 	{`(define (domain d)
 		(:constants c)
 		(:action a :parameters () :precondition (=  c c)))`,
-		"undefined", nil},
+		":equality", nil},
 	{`(define (domain d)
 		(:requirements :equality)
 		(:constants c)
 		(:action a :parameters () :precondition (=  c c)))`,
 		"", nil},
+	{`(define (domain d)
+		(:requirements :equality)
+		(:predicates (p ?x))
+		(:action a :parameters (?x ?y) :precondition (= ?x ?y) :effect (p ?x)))`,
+		"", nil},
+	{`(define (domain d)
+		(:requirements :equality)
+		(:constants c)
+		(:action a :parameters () :precondition (not (= c c))))`,
+		":negative-preconditions", nil},
+	{`(define (domain d)
+		(:requirements :equality :negative-preconditions)
+		(:constants c)
+		(:action a :parameters () :precondition (not (= c c))))`,
+		"", nil},
+	{`(define (domain d)
+		(:requirements :equality)
+		(:action a :parameters () :precondition (= c ?y)))`,
+		"undefined constant c", nil},
+	{`(define (domain d)
+		(:requirements :equality)
+		(:constants c)
+		(:predicates (p ?x))
+		(:action a :parameters (?x) :effect (not (= ?x c))))`,
+		"not allowed in effects", nil},
 
 	// universal-preconditions
 	{`(define (domain d)
@@ -243,12 +269,34 @@ var typesDefTests = []checkDomainTest{
 		checkSupers("t", []string{"t", "object"})},
 	{`(define (domain d) (:requirements :typing) (:types t - s s))`, "",
 		checkSupers("t", []string{"t", "s", "object"})},
-	{`(define (domain d) (:requirements :typing) (:types t - s s - t))`, "",
-		checkSupers("t", []string{"t", "s", "object"})},
 	{`(define (domain d) (:requirements :typing) (:types t - s s - u u))`, "",
 		checkSupers("t", []string{"t", "s", "u", "object"})},
 	{`(define (domain d) (:requirements :typing) (:types t - s s - u u))`, "",
 		checkSupers("s", []string{"s", "u", "object"})},
+
+	// either super types: the type is a sub type of each listed type.
+	{`(define (domain d) (:requirements :typing) (:types u - (either s t) s t))`, "",
+		checkSupers("u", []string{"u", "s", "t", "object"})},
+	{`(define (domain d) (:requirements :typing) (:types u - (either s t) s t))`, "",
+		checkSupers("s", []string{"s", "object"})},
+	{`(define (domain d) (:requirements :typing) (:types u - (either s t) s t) (:constants c - u))`, "",
+		domainChecks(checkTypeDomain("s", []string{"c"}),
+			checkTypeDomain("t", []string{"c"}),
+			checkTypeDomain("u", []string{"c"}))},
+
+	// diamond: v - s - u and v - t - u
+	{`(define (domain d) (:requirements :typing) (:types v - (either s t) s t - u u))`, "",
+		checkSupers("v", []string{"v", "s", "t", "u", "object"})},
+	{`(define (domain d) (:requirements :typing) (:types v - (either s t) s t - u u) (:constants c - v))`, "",
+		domainChecks(checkTypeDomain("u", []string{"c"}),
+			checkTypeDomain("object", []string{"c"}))},
+
+	// cycles
+	{`(define (domain d) (:requirements :typing) (:types t - t))`, "t is its own super type: t - t", nil},
+	{`(define (domain d) (:requirements :typing) (:types t - s s - t))`, "its own super type", nil},
+	{`(define (domain d) (:requirements :typing) (:types t - s s - u u - t))`, "its own super type", nil},
+	{`(define (domain d) (:requirements :typing) (:types v - (either s t) s t - v))`, "its own super type", nil},
+	{`(define (domain d) (:requirements :typing) (:types object - t t))`, "its own super type", nil},
 }
 
 // checkTypes returns a function that scans through
@@ -558,6 +606,21 @@ func TestCheckFuncsDef(t *testing.T) {
 	}
 }
 
+func TestFuncsDefRequirementOnce(t *testing.T) {
+	for _, pddl := range []string{
+		`(define (domain d) (:functions (f) (g) (h)))`,
+		`(define (domain d) (:requirements :typing) (:types t) (:functions (f) - t (g) - t))`,
+	} {
+		ast, err := Parse("", strings.NewReader(pddl))
+		if err != nil {
+			t.Fatalf("%s\nparse error: %s", pddl, err)
+		}
+		if errs := Check(ast.(*Domain), nil); len(errs) != 1 {
+			t.Errorf("%s\nexpected one missing requirement error, got %v", pddl, errs)
+		}
+	}
+}
+
 var actionsDefTests = []checkDomainTest{
 	{`(define (domain d) (:action a :parameters ()))`, "", nil},
 	{`(define (domain d) (:action a :parameters (?a)))`, "",
@@ -699,6 +762,20 @@ var literalNodeTests = []checkDomainTest{
 	{`(define (domain d) (:requirements :adl) (:types t - s s - u u) (:predicates (p ?a - u))
 		(:action a :parameters () :precondition (forall (?a - t) (p ?a))))`, "", nil},
 
+	// Diamond hierarchy: v - s - u and v - t - u
+	{`(define (domain d) (:requirements :adl) (:types v - (either s t) s t - u u) (:constants c - v)
+		(:predicates (p ?a - s) (q ?a - t) (r ?a - u))
+		(:action a :parameters () :precondition (and (p c) (q c) (r c))))`, "", nil},
+	{`(define (domain d) (:requirements :adl) (:types v - (either s t) s t - u u)
+		(:predicates (p ?a - s) (q ?a - t) (r ?a - u))
+		(:action a :parameters (?a - v) :precondition (and (p ?a) (q ?a) (r ?a))))`, "", nil},
+	{`(define (domain d) (:requirements :adl) (:types v - (either s t) s t - u u) (:constants c - s)
+		(:predicates (p ?a - v))
+		(:action a :parameters () :precondition (p c)))`, "incompatible", nil},
+	{`(define (domain d) (:requirements :adl) (:types v - (either s t) s t - u u) (:constants c - u)
+		(:predicates (p ?a - t))
+		(:action a :parameters () :precondition (p c)))`, "incompatible", nil},
+
 	// OK parameter types: wants (either s t), gets s or t
 	{`(define (domain d) (:requirements :adl) (:types s t) (:constants c - t)
 		(:predicates (p ?a - (either s t)))
@@ -774,6 +851,447 @@ func TestCheckLiteralNode(t *testing.T) {
 	}
 }
 
+const problemTestDomain = `(define (domain d)
+	(:requirements :typing :action-costs)
+	(:types t)
+	(:constants c - t)
+	(:predicates (p ?x - t))
+	(:functions (total-cost) (f ?x - t))
+	(:action a :parameters (?x - t) :effect (and (p ?x) (increase total-cost (f ?x)))))`
+
+var metricTests = []checkProblemTest{
+	{problemTestDomain, `(define (problem x) (:domain d) (:init) (:goal (and)))`, ""},
+	{problemTestDomain, `(define (problem x) (:domain d) (:init) (:goal (and))
+		(:metric minimize (total-cost)))`, ""},
+	{`(define (domain d) (:requirements :action-costs))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and))
+		(:metric minimize (total-cost)))`, "total-cost"},
+	{`(define (domain d) (:requirements :action-costs) (:functions (total-cost ?x)))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and))
+		(:metric minimize (total-cost)))`, "0-ary total-cost"},
+	{`(define (domain d))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and))
+		(:metric minimize (total-cost)))`, ":action-costs"},
+}
+
+func TestCheckMetric(t *testing.T) {
+	for _, test := range metricTests {
+		test.run(t)
+	}
+}
+
+var initTests = []checkProblemTest{
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects o - t)
+		(:init (p o) (p c)) (:goal (and)))`, ""},
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects o - t)
+		(:init (not (p o))) (:goal (and)))`, "negative"},
+	{problemTestDomain, `(define (problem x) (:domain d)
+		(:init (p ?x)) (:goal (and)))`, "variable \\?x in :init"},
+	{problemTestDomain, `(define (problem x) (:domain d)
+		(:init (= (f ?x) 1)) (:goal (and)))`, "variable \\?x in :init"},
+
+	// functions are assigned at most once
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects o - t)
+		(:init (= (f o) 1) (= (f c) 1)) (:goal (and)))`, ""},
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects o - t)
+		(:init (= (f o) 1) (= (f o) 2)) (:goal (and)))`, "multiple"},
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects o - t)
+		(:init (= (f o) 1) (= (F O) 1)) (:goal (and)))`, "multiple"},
+
+	// total-cost is initialized to 0
+	{problemTestDomain, `(define (problem x) (:domain d)
+		(:init (= (total-cost) 0)) (:goal (and)))`, ""},
+	{problemTestDomain, `(define (problem x) (:domain d)
+		(:init (= (total-cost) 0.0)) (:goal (and)))`, ""},
+	{problemTestDomain, `(define (problem x) (:domain d)
+		(:init (= (total-cost) 5)) (:goal (and)))`, "initialized to 0"},
+	{problemTestDomain, `(define (problem x) (:domain d)
+		(:init (= total-cost 0) (= (total-cost) 0)) (:goal (and)))`, "multiple"},
+}
+
+func TestCheckInit(t *testing.T) {
+	for _, test := range initTests {
+		test.run(t)
+	}
+}
+
+var processTests = []checkDomainTest{
+	{`(define (domain d) (:requirements :time) (:predicates (on)) (:functions (temp) (rate))
+		(:action heat :parameters () :effect (on))
+		(:process warming :parameters () :precondition (on)
+			:effect (and (increase (temp) (* #t (rate))) (decrease (rate) (* 0.5 #t))))
+		(:event overheat :parameters () :precondition (on) :effect (and (not (on)) (assign (temp) -5))))`,
+		"", nil},
+	{`(define (domain d) (:requirements :time) (:functions (temp))
+		(:process warming :parameters () :effect (increase (temp) #t)))`, "", nil},
+	{`(define (domain d) (:requirements :action-costs) (:functions (temp))
+		(:process warming :parameters () :effect (increase (temp) #t)))`, ":time", nil},
+	{`(define (domain d) (:predicates (on))
+		(:event e :parameters () :effect (on)))`, ":time", nil},
+	{`(define (domain d) (:requirements :action-costs) (:functions (temp))
+		(:action a :parameters () :effect (increase (temp) #t)))`, "^:2: continuous effect outside", nil},
+	{`(define (domain d) (:requirements :time) (:functions (temp))
+		(:action a :parameters () :effect (increase (temp) (* #t 2))))`, "outside of a process", nil},
+	{`(define (domain d) (:requirements :time) (:functions (temp))
+		(:event e :parameters () :effect (increase (temp) (* #t 2))))`, "outside of a process", nil},
+	{`(define (domain d) (:requirements :time) (:functions (temp))
+		(:process p :parameters () :effect (assign (temp) (* #t 2))))`, "assign is not allowed", nil},
+	{`(define (domain d) (:requirements :time) (:functions (temp))
+		(:process p :parameters () :effect (increase (temp) 2)))`, "must be continuous", nil},
+	{`(define (domain d) (:requirements :time) (:predicates (on))
+		(:process p :parameters () :effect (on)))`, "must be continuous", nil},
+	{`(define (domain d) (:requirements :time) (:functions (temp))
+		(:process p :parameters () :effect (increase (temp) (* #t (rate)))))`, "undefined function", nil},
+	{`(define (domain d) (:requirements :action-costs) (:functions (total-cost))
+		(:action a :parameters () :effect (decrease (total-cost) 1)))`, ":time", nil},
+}
+
+func TestCheckProcesses(t *testing.T) {
+	for _, test := range processTests {
+		test.run(t)
+	}
+}
+
+var probabilisticTests = []checkDomainTest{
+	{`(define (domain d) (:requirements :probabilistic-effects :conditional-effects) (:predicates (p) (q))
+		(:action a :parameters () :effect (probabilistic 0.3 (p) 0.7 (and (q) (not (p))))))`, "", nil},
+	{`(define (domain d) (:requirements :probabilistic-effects :conditional-effects) (:predicates (p) (q))
+		(:action a :parameters () :effect (and (q) (when (q) (probabilistic 0.5 (p))))))`, "", nil},
+	{`(define (domain d) (:requirements :probabilistic-effects) (:predicates (p) (q))
+		(:action a :parameters () :effect (probabilistic 0.1 (p) 0.2 (q) 0.7 (and))))`, "", nil},
+	{`(define (domain d) (:predicates (p))
+		(:action a :parameters () :effect (probabilistic 0.5 (p))))`, ":probabilistic-effects", nil},
+	{`(define (domain d) (:requirements :probabilistic-effects) (:predicates (p))
+		(:action a :parameters () :effect (probabilistic 1.5 (p))))`, "not in \\[0,1\\]", nil},
+	{`(define (domain d) (:requirements :probabilistic-effects) (:predicates (p))
+		(:action a :parameters () :effect (probabilistic -0.5 (p))))`, "not in \\[0,1\\]", nil},
+	{`(define (domain d) (:requirements :probabilistic-effects) (:predicates (p) (q))
+		(:action a :parameters () :effect (probabilistic 0.6 (p) 0.6 (q))))`, "more than 1", nil},
+	{`(define (domain d) (:requirements :probabilistic-effects) (:predicates (p))
+		(:action a :parameters () :effect (probabilistic 0.5 (r))))`, "undefined predicate", nil},
+}
+
+func TestCheckProbabilistic(t *testing.T) {
+	for _, test := range probabilisticTests {
+		test.run(t)
+	}
+}
+
+const timedInitDomain = `(define (domain d)
+	(:requirements :typing :action-costs :timed-initial-literals)
+	(:types t)
+	(:constants c - t)
+	(:predicates (p ?x - t) (at ?x ?y - t))
+	(:functions (total-cost) (f ?x - t)))`
+
+var timedInitTests = []checkProblemTest{
+	{timedInitDomain, `(define (problem x) (:domain d)
+		(:init (at 10 (p c)) (at 20.5 (not (p c)))) (:goal (and)))`, ""},
+	{timedInitDomain, `(define (problem x) (:domain d)
+		(:init (= (f c) 1) (at 0 (= (f c) 2)) (at 5 (= (f c) 3))) (:goal (and)))`, ""},
+	{timedInitDomain, `(define (problem x) (:domain d)
+		(:init (at c c) (AT 1 (at c c))) (:goal (and)))`, ""},
+	{timedInitDomain, `(define (problem x) (:domain d)
+		(:init (at -1 (p c))) (:goal (and)))`, "negative time"},
+	{timedInitDomain, `(define (problem x) (:domain d)
+		(:init (at 1 (p ?x))) (:goal (and)))`, "variable \\?x in :init"},
+	{timedInitDomain, `(define (problem x) (:domain d)
+		(:init (at 1 (= (f ?x) 1))) (:goal (and)))`, "variable \\?x in :init"},
+	{timedInitDomain, `(define (problem x) (:domain d)
+		(:init (at 1 (q c))) (:goal (and)))`, "undefined predicate"},
+	{timedInitDomain, `(define (problem x) (:domain d)
+		(:init (= (f c) (f c))) (:goal (and)))`, "function value \\(f c\\) in :init"},
+	{timedInitDomain, `(define (problem x) (:domain d)
+		(:init (at 1 (= (f c) (f c)))) (:goal (and)))`, "function value"},
+	{`(define (domain d) (:predicates (p)))`, `(define (problem x) (:domain d)
+		(:init (at 1 (p))) (:goal (and)))`, ":timed-initial-literals"},
+}
+
+func TestCheckTimedInit(t *testing.T) {
+	for _, test := range timedInitTests {
+		test.run(t)
+	}
+}
+
+const objectFluentDomain = `(define (domain d)
+	(:requirements :typing :object-fluents)
+	(:types t s - object u - t)
+	(:constants c - t e - s)
+	(:predicates (p ?x - t) (q ?x - s))
+	(:functions (loc ?x - t) - t (near ?x - t) - u (other) - s))`
+
+var objectFluentTests = []checkProblemTest{
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init (= (loc c) c) (= (other) e)) (:goal (p (loc c))))`, ""},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init) (:goal (p (loc (loc c)))))`, ""},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init) (:goal (p (near c))))`, ""},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init) (:goal (= (loc c) c)))`, ":equality"},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init) (:goal (q (loc c))))`, "incompatible"},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init) (:goal (p (loc e))))`, "incompatible"},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init) (:goal (p (nowhere c))))`, "undefined function"},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init (p (loc c))) (:goal (and)))`, "function term"},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init (= (loc c) e)) (:goal (and)))`, "incompatible"},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init (= (loc c) 1)) (:goal (and)))`, "number"},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init (= (loc c) ?x)) (:goal (and)))`, "variable"},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init (= (near c) c)) (:goal (and)))`, "incompatible"},
+	{objectFluentDomain, `(define (problem x) (:domain d) (:init (= (loc c) (loc c))) (:goal (and)))`, "function value"},
+
+	// assignments in effects
+	{`(define (domain d) (:requirements :typing :object-fluents) (:types t)
+		(:functions (loc ?x - t) - t)
+		(:action a :parameters (?x ?y - t) :effect (and (assign (loc ?x) ?y) (assign (loc ?y) (loc ?x)))))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and)))`, ""},
+	{`(define (domain d) (:requirements :typing :object-fluents) (:types t s)
+		(:functions (loc ?x - t) - t)
+		(:action a :parameters (?x - t ?y - s) :effect (assign (loc ?x) ?y)))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and)))`, "incompatible"},
+	{`(define (domain d) (:requirements :typing :object-fluents) (:types t)
+		(:functions (loc ?x - t) - t)
+		(:action a :parameters (?x - t) :effect (increase (loc ?x) ?x)))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and)))`, "increase is not allowed"},
+
+	// requirements
+	{`(define (domain d) (:requirements :typing) (:types t) (:functions (loc ?x - t) - t))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and)))`, ":object-fluents"},
+	{`(define (domain d) (:requirements :typing :object-fluents) (:types t) (:functions (loc ?x - t) - t))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and)))`, ""},
+	{`(define (domain d) (:requirements :typing :object-fluents) (:types t) (:functions (loc ?x - t) - number))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and)))`, ":action-costs"},
+	{`(define (domain d) (:requirements :typing :object-fluents) (:types t) (:functions (loc ?x - t) - v))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and)))`, "undefined type"},
+	{`(define (domain d) (:requirements :typing :action-costs :object-fluents) (:types t)
+		(:predicates (p ?x - t)) (:functions (f ?x - t)))`,
+		`(define (problem x) (:domain d) (:objects o - t) (:init) (:goal (p (f o))))`, "numeric function"},
+}
+
+func TestObjectFluents(t *testing.T) {
+	for _, test := range objectFluentTests {
+		test.run(t)
+	}
+}
+
+var goalTests = []checkProblemTest{
+	{problemTestDomain, `(define (problem x) (:domain d) (:init) (:goal (p c)))`, ""},
+	{problemTestDomain, `(define (problem x) (:domain d) (:requirements :adl)
+		(:init) (:goal (forall (?x - t) (p ?x))))`, ""},
+	{problemTestDomain, `(define (problem x) (:domain d) (:init) (:goal (p ?x)))`,
+		"undefined variable"},
+	{problemTestDomain, `(define (problem x) (:domain d) (:requirements :adl)
+		(:init) (:goal (forall (?x - t) (p ?y))))`, "undefined variable"},
+}
+
+func TestCheckGoal(t *testing.T) {
+	for _, test := range goalTests {
+		test.run(t)
+	}
+}
+
+var objectsTests = []checkProblemTest{
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects o - t) (:init) (:goal (and)))`, ""},
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects c - t) (:init) (:goal (and)))`,
+		"shadows constant"},
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects C - t) (:init) (:goal (and)))`,
+		"shadows constant"},
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects o o - t) (:init) (:goal (and)))`,
+		"multiple"},
+}
+
+func TestCheckObjects(t *testing.T) {
+	for _, test := range objectsTests {
+		test.run(t)
+	}
+}
+
+var codeTests = []struct {
+	domain, problem, code string
+}{
+	{problemTestDomain, `(define (problem x) (:domain e) (:init) (:goal (and)))`,
+		CodeDomainMismatch},
+	{problemTestDomain, `(define (problem x) (:domain d) (:init) (:goal (q)))`,
+		CodeUndefined},
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects o o - t) (:init) (:goal (and)))`,
+		CodeRedefinition},
+	{problemTestDomain, `(define (problem x) (:domain d) (:init) (:goal (p c c)))`,
+		CodeArity},
+	{problemTestDomain, `(define (problem x) (:domain d) (:objects o) (:init) (:goal (p o)))`,
+		CodeTypeMismatch},
+	{problemTestDomain, `(define (problem x) (:domain d) (:init) (:goal (or (p c) (p c))))`,
+		CodeMissingRequirement},
+}
+
+func TestErrorCodes(t *testing.T) {
+	for _, test := range codeTests {
+		_, _, errs := parseChecked(t, test.domain, test.problem)
+		if len(errs) == 0 {
+			t.Errorf("%s\nexpected a %s error", test.problem, test.code)
+			continue
+		}
+		c, ok := errs[0].(Coder)
+		if !ok {
+			t.Errorf("%s\nerror %s has no code", test.problem, errs[0])
+			continue
+		}
+		if c.Code() != test.code {
+			t.Errorf("%s\nexpected code %s, got %s (%s)", test.problem, test.code, c.Code(), errs[0])
+		}
+	}
+}
+
+func TestRedefinitionError(t *testing.T) {
+	_, _, errs := parseChecked(t, problemTestDomain, `(define (problem x) (:domain d)
+		(:objects o
+			o - t)
+		(:init) (:goal (and)))`)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	r, ok := errs[0].(RedefinitionError)
+	if !ok {
+		t.Fatalf("expected a RedefinitionError, got %T", errs[0])
+	}
+	if r.Kind != "object" || r.Name.Str != "o" || r.Previous.Str != "o" ||
+		r.Previous.Line != 2 || r.Name.Line != 3 {
+		t.Errorf("unexpected redefinition: %+v", r)
+	}
+}
+
+func TestCheckMultipleProblems(t *testing.T) {
+	ast, err := Parse("", strings.NewReader(problemTestDomain))
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	}
+	d := ast.(*Domain)
+	if errs := Check(d, nil); len(errs) > 0 {
+		t.Fatalf("unexpected error: %s", errs[0])
+	}
+	for _, obj := range []string{"o1", "o2"} {
+		ast, err := Parse("", strings.NewReader(`(define (problem x) (:domain d)
+			(:objects `+obj+` - t) (:init) (:goal (and)))`))
+		if err != nil {
+			t.Fatalf("parse error: %s", err)
+		}
+		if errs := Check(d, ast.(*Problem)); len(errs) > 0 {
+			t.Fatalf("unexpected error: %s", errs[0])
+		}
+		typ := findType("t", d.Types)
+		if len(typ.Domain) != 2 || typ.Domain[1].Str != obj {
+			t.Errorf("expected type t to have objects c and %s, got %v", obj, typ.Domain)
+		}
+	}
+}
+
+func TestCheckTwice(t *testing.T) {
+	const pddl = `(define (domain d)
+		(:requirements :universal-preconditions)
+		(:constants c)
+		(:predicates (p ?x) (q ?x ?y))
+		(:action a :parameters (?x ?y)
+			:precondition (forall (?z) (q ?x ?z))
+			:effect (p ?y)))`
+	ast, err := Parse("", strings.NewReader(pddl))
+	if err != nil {
+		t.Fatalf("%s\nparse error: %s", pddl, err)
+	}
+	d := ast.(*Domain)
+	for i := 0; i < 2; i++ {
+		if errs := Check(d, nil); len(errs) > 0 {
+			t.Fatalf("%s\ncheck %d: unexpected error: %s", pddl, i+1, errs[0])
+		}
+	}
+	if typ := findType("object", d.Types); len(typ.Domain) != 1 {
+		t.Errorf("expected type object to have constant c, got %v", typ.Domain)
+	}
+}
+
+func TestCheckUntypedMultipleProblems(t *testing.T) {
+	ast, err := Parse("", strings.NewReader(`(define (domain d) (:constants c) (:predicates (p ?x))
+		(:action a :parameters (?x) :precondition (p ?x) :effect (not (p ?x))))`))
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	}
+	d := ast.(*Domain)
+	if errs := Check(d, nil); len(errs) > 0 {
+		t.Fatalf("unexpected error: %s", errs[0])
+	}
+	for _, obj := range []string{"o1", "o2", "o3"} {
+		ast, err := Parse("", strings.NewReader(`(define (problem x) (:domain d)
+			(:objects `+obj+`) (:init (p `+obj+`)) (:goal (p c)))`))
+		if err != nil {
+			t.Fatalf("parse error: %s", err)
+		}
+		if errs := Check(d, ast.(*Problem)); len(errs) > 0 {
+			t.Fatalf("problem with %s: unexpected error: %s", obj, errs[0])
+		}
+		typ := findType("object", d.Types)
+		if len(typ.Domain) != 2 || typ.Domain[1].Str != obj {
+			t.Errorf("expected type object to have objects c and %s, got %v", obj, typ.Domain)
+		}
+	}
+}
+
+// CheckProblemErrors parses a domain and a problem and
+// returns the errors from checking them.
+// ParseChecked parses a domain and, unless prob is empty, a problem,
+// and returns them with the errors reported by Check.  The test fails
+// if either does not parse.
+func parseChecked(t *testing.T, dom, prob string) (*Domain, *Problem, []error) {
+	ast, err := Parse("", strings.NewReader(dom))
+	if err != nil {
+		t.Fatalf("%s\nparse error: %s", dom, err)
+	}
+	d := ast.(*Domain)
+	var p *Problem
+	if prob != "" {
+		ast, err := Parse("", strings.NewReader(prob))
+		if err != nil {
+			t.Fatalf("%s\nparse error: %s", prob, err)
+		}
+		p = ast.(*Problem)
+	}
+	return d, p, Check(d, p)
+}
+
+// MustCheck is like parseChecked, but the test also
+// fails if there is an error without an ignored code.
+func mustCheck(t *testing.T, dom, prob string, ignore ...string) (*Domain, *Problem) {
+	d, p, errs := parseChecked(t, dom, prob)
+	for _, err := range errs {
+		if c, ok := err.(Coder); !ok || !containsString(ignore, c.Code()) {
+			t.Fatalf("%s\n%s\ncheck error: %s", dom, prob, err)
+		}
+	}
+	return d, p
+}
+
+func containsString(strs []string, s string) bool {
+	for _, t := range strs {
+		if t == s {
+			return true
+		}
+	}
+	return false
+}
+
+type checkProblemTest struct {
+	domain      string
+	problem     string
+	errorRegexp string
+}
+
+func (c checkProblemTest) run(t *testing.T) {
+	switch _, _, errs := parseChecked(t, c.domain, c.problem); {
+	case len(errs) == 0 && c.errorRegexp != "":
+		t.Errorf("%s\nexpected error matching '%s'", c.problem, c.errorRegexp)
+	case len(errs) > 0 && c.errorRegexp == "":
+		t.Errorf("%s\nunexpected error '%s'", c.problem, errs[0])
+	case len(errs) > 0 && c.errorRegexp != "":
+		re := regexp.MustCompile(c.errorRegexp)
+		if !re.MatchString(errs[0].Error()) {
+			t.Errorf("%s\nexpected error matching '%s', got '%s'",
+				c.problem, c.errorRegexp, errs[0])
+		}
+	}
+}
+
 type checkDomainTest struct {
 	pddl        string
 	errorRegexp string
@@ -803,12 +1321,88 @@ func (c checkDomainTest) run(t *testing.T) {
 	case len(errs) == 0 && c.errorRegexp != "":
 		t.Errorf("%s\nexpected error matching '%s'", c.pddl, c.errorRegexp)
 	case len(errs) > 0 && c.errorRegexp == "":
-		t.Errorf("%s\nunexpected error '%s'", c.pddl, err)
+		t.Errorf("%s\nunexpected error '%s'", c.pddl, errs[0])
 	case len(errs) > 0 && c.errorRegexp != "":
 		re := regexp.MustCompile(c.errorRegexp)
 		if !re.Match([]byte(errs[0].Error())) {
 			t.Errorf("%s\nexpected error matching '%s', got '%s'",
-				c.pddl, c.errorRegexp, err.Error())
+				c.pddl, c.errorRegexp, errs[0])
 		}
 	}
 }
+
+// MixCase returns the string with the case of every other letter
+// flipped, so that the same name is spelled differently at
+// different places in the string.
+func mixCase(s string) string {
+	rs := []rune(s)
+	flip := false
+	for i, r := range rs {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if flip {
+			if unicode.IsUpper(r) {
+				rs[i] = unicode.ToLower(r)
+			} else {
+				rs[i] = unicode.ToUpper(r)
+			}
+		}
+		flip = !flip
+	}
+	return string(rs)
+}
+
+func TestMixedCase(t *testing.T) {
+	var tests []checkProblemTest
+	for _, ts := range [][]checkProblemTest{metricTests, initTests, goalTests, objectsTests} {
+		tests = append(tests, ts...)
+	}
+	for _, test := range codeTests {
+		tests = append(tests, checkProblemTest{test.domain, test.problem, test.code})
+	}
+	for _, test := range tests {
+		_, _, errs := parseChecked(t, test.domain, test.problem)
+		want := errorCodes(errs)
+		_, _, errs = parseChecked(t, mixCase(test.domain), mixCase(test.problem))
+		mixed := errorCodes(errs)
+		if want != mixed {
+			t.Errorf("%s\n%s\nexpected errors [%s] with mixed case, got [%s]",
+				mixCase(test.domain), mixCase(test.problem), want, mixed)
+		}
+	}
+
+	for _, test := range lintTests {
+		want := lintKinds(t, test.domain, test.problem)
+		mixed := lintKinds(t, mixCase(test.domain), mixCase(test.problem))
+		if want != mixed {
+			t.Errorf("%s\n%s\nexpected warnings [%s] with mixed case, got [%s]",
+				mixCase(test.domain), mixCase(test.problem), want, mixed)
+		}
+	}
+}
+
+// ErrorCodes returns a string of the codes of the errors,
+// using "error" for errors without a code.
+func errorCodes(errs []error) string {
+	var codes []string
+	for _, err := range errs {
+		code := "error"
+		if c, ok := err.(Coder); ok {
+			code = c.Code()
+		}
+		codes = append(codes, code)
+	}
+	return strings.Join(codes, " ")
+}
+
+// LintKinds returns a string of the kinds of
+// warnings for the domain and problem.
+func lintKinds(t *testing.T, dom, prob string) string {
+	d, p := mustCheck(t, dom, prob, CodeMissingRequirement)
+	var kinds []string
+	for _, w := range Lint(d, p) {
+		kinds = append(kinds, w.Kind)
+	}
+	return strings.Join(kinds, " ")
+}
@@ -0,0 +1,194 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+// Happenings returns the actions, processes, and events of the domain.
+func (d *Domain) Happenings() []*Action {
+	var acts []*Action
+	for _, lst := range [][]Action{d.Actions, d.Processes, d.Events} {
+		for i := range lst {
+			acts = append(acts, &lst[i])
+		}
+	}
+	return acts
+}
+
+// Reads returns the predicates that are read by the action: those that
+// appear in its precondition or in the condition of one of its
+// conditional effects.  Each predicate is returned once, in the order
+// of its first appearance.
+//
+// The action must have been checked so that its literals are linked
+// to their predicate definitions.
+func (a *Action) Reads() []*Predicate {
+	var preds predSet
+	a.visitConds(func(l *LiteralNode, _ bool) {
+		preds.add(l.Definition)
+	})
+	return preds
+}
+
+// Writes returns the predicates that are written by the action: those
+// that appear in an unconditional effect or as the consequent of a
+// conditional effect.  Each predicate is returned once, in the order
+// of its first appearance.
+//
+// The action must have been checked so that its literals are linked
+// to their predicate definitions.
+func (a *Action) Writes() []*Predicate {
+	var preds predSet
+	a.visitEffects(func(l *LiteralNode, _ bool) {
+		preds.add(l.Definition)
+	})
+	return preds
+}
+
+// Enables returns true if the action may enable the action b: if it
+// has an effect that adds a predicate that b reads positively, or that
+// deletes a predicate that b reads negatively.  This is a lifted
+// approximation; the arguments of the literals are not considered.
+//
+// Both actions must have been checked.
+func (a *Action) Enables(b *Action) bool {
+	var adds, dels predSet
+	a.visitEffects(func(l *LiteralNode, pos bool) {
+		if pos {
+			adds.add(l.Definition)
+		} else {
+			dels.add(l.Definition)
+		}
+	})
+	enables := false
+	b.visitConds(func(l *LiteralNode, pos bool) {
+		if pos && adds.has(l.Definition) || !pos && dels.has(l.Definition) {
+			enables = true
+		}
+	})
+	return enables
+}
+
+// VisitConds calls the function for each literal in the precondition
+// of the action and in the conditions of its conditional effects,
+// along with whether the literal is required to be true or false.
+func (a *Action) visitConds(fn func(*LiteralNode, bool)) {
+	if a.Precondition != nil {
+		visitLiterals(a.Precondition, true, fn)
+	}
+	if a.Effect != nil {
+		visitLiterals(a.Effect, true, func(l *LiteralNode, pos bool) {
+			if !l.IsEffect {
+				fn(l, pos)
+			}
+		})
+	}
+}
+
+// VisitEffects calls the function for each literal in the effect of
+// the action that is not part of a condition, along with whether the
+// literal is added or deleted.
+func (a *Action) visitEffects(fn func(*LiteralNode, bool)) {
+	if a.Effect != nil {
+		visitLiterals(a.Effect, true, func(l *LiteralNode, pos bool) {
+			if l.IsEffect {
+				fn(l, pos)
+			}
+		})
+	}
+}
+
+// A predSet is a slice of distinct predicates.
+type predSet []*Predicate
+
+// Add adds a predicate to the set if it is non-nil and not already a member.
+func (s *predSet) add(p *Predicate) {
+	if p == nil {
+		return
+	}
+	for _, q := range *s {
+		if q == p {
+			return
+		}
+	}
+	*s = append(*s, p)
+}
+
+// Has returns true if the predicate is a member of the set.
+func (s predSet) has(p *Predicate) bool {
+	for _, q := range s {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// VisitLiterals calls the function for each literal in the formula,
+// along with whether the literal appears positively.  The polarity of
+// a literal is flipped by its own negation, by enclosing not nodes, and
+// by being in the antecedent of an imply node; pos is the polarity of
+// the formula itself.
+func visitLiterals(f Formula, pos bool, fn func(*LiteralNode, bool)) {
+	switch n := f.(type) {
+	case *LiteralNode:
+		fn(n, pos != n.Negative)
+	case *AndNode:
+		for _, g := range n.Formula {
+			visitLiterals(g, pos, fn)
+		}
+	case *OrNode:
+		for _, g := range n.Formula {
+			visitLiterals(g, pos, fn)
+		}
+	case *NotNode:
+		visitLiterals(n.Formula, !pos, fn)
+	case *ImplyNode:
+		visitLiterals(n.Left, !pos, fn)
+		visitLiterals(n.Right, pos, fn)
+	case *ForallNode:
+		visitLiterals(n.Formula, pos, fn)
+	case *ExistsNode:
+		visitLiterals(n.Formula, pos, fn)
+	case *WhenNode:
+		visitLiterals(n.Condition, pos, fn)
+		visitLiterals(n.Formula, pos, fn)
+	case *ProbabilisticNode:
+		for _, g := range n.Formula {
+			visitLiterals(g, pos, fn)
+		}
+	}
+}
+
+// WalkFormula calls the function for the formula and each of its
+// sub-formulas in pre-order.  The conditions of conditional effects
+// are visited before their consequents.
+func walkFormula(f Formula, fn func(Formula)) {
+	fn(f)
+	switch n := f.(type) {
+	case *AndNode:
+		for _, g := range n.Formula {
+			walkFormula(g, fn)
+		}
+	case *OrNode:
+		for _, g := range n.Formula {
+			walkFormula(g, fn)
+		}
+	case *NotNode:
+		walkFormula(n.Formula, fn)
+	case *ImplyNode:
+		walkFormula(n.Left, fn)
+		walkFormula(n.Right, fn)
+	case *ForallNode:
+		walkFormula(n.Formula, fn)
+	case *ExistsNode:
+		walkFormula(n.Formula, fn)
+	case *WhenNode:
+		walkFormula(n.Condition, fn)
+		walkFormula(n.Formula, fn)
+	case *TimedNode:
+		walkFormula(n.Formula, fn)
+	case *ProbabilisticNode:
+		for _, g := range n.Formula {
+			walkFormula(g, fn)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import "testing"
+
+var readsWritesTests = []struct {
+	pddl          string
+	reads, writes []string
+}{
+	{`(define (domain d) (:action a :parameters ()))`, nil, nil},
+	{`(define (domain d) (:predicates (p) (q))
+		(:action a :parameters () :precondition (p) :effect (q)))`,
+		[]string{"p"}, []string{"q"}},
+	{`(define (domain d) (:requirements :adl) (:predicates (p) (q) (r))
+		(:action a :parameters () :precondition (and (p) (not (q)) (p))
+			:effect (and (not (p)) (r))))`,
+		[]string{"p", "q"}, []string{"p", "r"}},
+	{`(define (domain d) (:requirements :adl) (:predicates (p ?x) (q) (r))
+		(:action a :parameters ()
+			:precondition (forall (?x) (imply (p ?x) (q)))
+			:effect (forall (?x) (when (q) (r)))))`,
+		[]string{"p", "q"}, []string{"r"}},
+}
+
+func TestReadsWrites(t *testing.T) {
+	for _, test := range readsWritesTests {
+		d, _ := mustCheck(t, test.pddl, "")
+		act := &d.Actions[0]
+		if s := predNames(act.Reads()); !sameStrings(s, test.reads) {
+			t.Errorf("%s\nexpected reads %v, got %v", test.pddl, test.reads, s)
+		}
+		if s := predNames(act.Writes()); !sameStrings(s, test.writes) {
+			t.Errorf("%s\nexpected writes %v, got %v", test.pddl, test.writes, s)
+		}
+	}
+}
+
+func predNames(ps []*Predicate) (names []string) {
+	for _, p := range ps {
+		names = append(names, p.Str)
+	}
+	return
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var enablesTests = []struct {
+	pddl    string
+	enables bool
+}{
+	{`(define (domain d) (:predicates (p) (q))
+		(:action a :parameters () :effect (p))
+		(:action b :parameters () :precondition (p)))`, true},
+	{`(define (domain d) (:predicates (p) (q))
+		(:action a :parameters () :effect (q))
+		(:action b :parameters () :precondition (p)))`, false},
+	{`(define (domain d) (:requirements :adl) (:predicates (p))
+		(:action a :parameters () :effect (not (p)))
+		(:action b :parameters () :precondition (p)))`, false},
+	{`(define (domain d) (:requirements :adl) (:predicates (p))
+		(:action a :parameters () :effect (not (p)))
+		(:action b :parameters () :precondition (not (p))))`, true},
+	{`(define (domain d) (:requirements :adl) (:predicates (p))
+		(:action a :parameters () :effect (not (p)))
+		(:action b :parameters () :precondition (not (and (p)))))`, true},
+	{`(define (domain d) (:requirements :adl) (:predicates (p) (q))
+		(:action a :parameters () :effect (not (p)))
+		(:action b :parameters () :precondition (imply (p) (q))))`, true},
+	{`(define (domain d) (:requirements :adl) (:predicates (p) (q))
+		(:action a :parameters () :effect (p))
+		(:action b :parameters () :effect (when (p) (q))))`, true},
+}
+
+func TestEnables(t *testing.T) {
+	for _, test := range enablesTests {
+		d, _ := mustCheck(t, test.pddl, "")
+		if e := d.Actions[0].Enables(&d.Actions[1]); e != test.enables {
+			t.Errorf("%s\nexpected Enables=%t, got %t", test.pddl, test.enables, e)
+		}
+	}
+}
@@ -4,45 +4,78 @@ package pddl
 
 import (
 	"io"
-	"log"
+	"strings"
 )
 
 // Parse returns either a Domain, a Problem or a parse error.
+// It parses the IPCDialect, ignoring its warnings.
 func Parse(file string, r io.Reader) (ast interface{}, err error) {
-	defer func() {
-		r := recover()
-		if r == nil {
-			return
-		}
-		if e, ok := r.(Error); ok {
-			err = e
-		} else {
-			panic(r)
-		}
-	}()
-	p, err := newParser(file, r)
-	if err != nil {
+	ast, _, err = ParseWithOptions(file, r, IPCDialect)
+	return
+}
+
+// ParseWithOptions returns either a Domain, a Problem or a parse error,
+// allowing the leniencies given by the options.  The returned warnings
+// report the uses of allowed leniencies.
+func ParseWithOptions(file string, r io.Reader, opts ParseOptions) (ast interface{}, ws []Warning, err error) {
+	defer recoverError(&err)
+	p := newParser(file, r, opts)
+	return parseDefine(p), p.warnings, nil
+}
+
+// ParseAll returns the Domains and Problems of each define in the input,
+// in order, or a parse error.  It parses the IPCDialect, ignoring its
+// warnings.
+func ParseAll(file string, r io.Reader) (asts []interface{}, err error) {
+	asts, _, err = ParseAllWithOptions(file, r, IPCDialect)
+	return
+}
+
+// ParseAllWithOptions is like ParseAll, but it allows the leniencies
+// given by the options, as with ParseWithOptions.
+func ParseAllWithOptions(file string, r io.Reader, opts ParseOptions) (asts []interface{}, ws []Warning, err error) {
+	defer recoverError(&err)
+	p := newParser(file, r, opts)
+	for p.peek().typ != tokEof {
+		asts = append(asts, parseDefine(p))
+	}
+	return asts, p.warnings, nil
+}
+
+// RecoverError recovers from a panic with a parse error, setting
+// the error.  Other panics are re-panicked.
+func recoverError(err *error) {
+	r := recover()
+	if r == nil {
 		return
 	}
+	if e, ok := r.(Error); ok {
+		*err = e
+	} else {
+		panic(r)
+	}
+}
+
+func parseDefine(p *parser) interface{} {
 	p.expect("(", "define")
 	defer p.expect(")")
-
-	if p.peekn(2).text == "domain" {
-		return parseDomain(p), nil
+	if p.peekn(2).key == "domain" {
+		return parseDomain(p)
 	}
-	return parseProblem(p), nil
+	return parseProblem(p)
 }
 
 func parseDomain(p *parser) *Domain {
-	return &Domain{
+	d := &Domain{
 		Name:         parseDomainName(p),
 		Requirements: parseReqsDef(p),
 		Types:        parseTypesDef(p),
 		Constants:    parseConstsDef(p),
 		Predicates:   parsePredsDef(p),
 		Functions:    parseFuncsDef(p),
-		Actions:      parseActionsDef(p),
 	}
+	parseStructuresDef(p, d)
+	return d
 }
 
 func parseDomainName(p *parser) Name {
@@ -64,6 +97,8 @@ func parseReqsDef(p *parser) (reqs []Name) {
 func parseTypesDef(p *parser) (types []Type) {
 	if p.accept("(", ":types") {
 		defer p.expect(")")
+		p.typesDef = true
+		defer func() { p.typesDef = false }()
 		for _, t := range parseTypedListString(p, tokName) {
 			types = append(types, Type{TypedEntry: t})
 		}
@@ -117,19 +152,28 @@ func parseFuncsDef(p *parser) []Function {
 	return nil
 }
 
-func parseActionsDef(p *parser) (acts []Action) {
+// ParseStructuresDef parses the actions, processes, and events of a domain,
+// which may be given in any order.
+func parseStructuresDef(p *parser, d *Domain) {
 	for p.peek().typ == tokOpen {
-		acts = append(acts, parseActionDef(p))
+		switch p.peekn(2).key {
+		case ":process":
+			d.Processes = append(d.Processes, parseActionDef(p, ":process"))
+		case ":event":
+			d.Events = append(d.Events, parseActionDef(p, ":event"))
+		default:
+			d.Actions = append(d.Actions, parseActionDef(p, ":action"))
+		}
 	}
-	return
 }
 
 func parseTypedListString(p *parser, typ tokenType) (lst []TypedEntry) {
 	for {
 		ids := parseNames(p, typ)
 		if len(ids) == 0 && p.peek().typ == tokMinus {
-			log.Println("Parser hack: allowing an empty name list in front of a type in a typed list")
-			log.Println("This seems to be required for IPC 2008 woodworking-strips/p11-domain.pddl")
+			// Required for IPC 2008 woodworking-strips/p11-domain.pddl.
+			p.lenient(p.opts.AllowEmptyTypedNames, p, EmptyTypedNames,
+				"type with no names in a typed list")
 		} else if len(ids) == 0 {
 			break
 		}
@@ -145,11 +189,20 @@ func parseType(p *parser) (typ []TypeName) {
 	if !p.accept("-") {
 		return
 	}
+	return parseTypeNames(p)
+}
+
+// ParseTypeNames parses either a type name or an either type.
+func parseTypeNames(p *parser) (typ []TypeName) {
 	if !p.accept("(") {
 		return []TypeName{{Name: parseName(p, tokName)}}
 	}
 	p.expect("either")
 	defer p.expect(")")
+	if p.typesDef {
+		p.lenient(p.opts.AllowEitherSupertypes, p, EitherSupertype,
+			"either supertype")
+	}
 	for _, id := range parseNamesPlus(p, tokName) {
 		typ = append(typ, TypeName{Name: id})
 	}
@@ -174,20 +227,19 @@ func parseFunctionTypedList(p *parser) (funs []Function) {
 	return
 }
 
+// ParseFunctionType parses the type of a function, which
+// is either number or, for object fluents, an object type.
 func parseFunctionType(p *parser) (typ []TypeName) {
 	if !p.accept("-") {
 		return
 	}
-	return []TypeName{TypeName{
-		Name: Name{
-			Location: p.Loc(),
-			Str:      p.expectText("number").text,
-		},
-	}}
+	return parseTypeNames(p)
 }
 
-func parseActionDef(p *parser) (act Action) {
-	p.expect("(", ":action")
+// ParseActionDef parses an action, or a process or an event, which
+// have the same syntax as an action, given the keyword of the definition.
+func parseActionDef(p *parser, kind string) (act Action) {
+	p.expect("(", kind)
 	defer p.expect(")")
 	act.Name = parseName(p, tokName)
 	act.Parameters = parseActParms(p)
@@ -205,6 +257,11 @@ func parseActionDef(p *parser) (act Action) {
 }
 
 func parseActParms(p *parser) (parms []TypedEntry) {
+	if p.peek().key != ":parameters" {
+		p.lenient(p.opts.AllowMissingParameters, p, MissingParameters,
+			"action without :parameters")
+		return nil
+	}
 	p.expect(":parameters", "(")
 	defer p.expect(")")
 	return parseTypedListString(p, tokQname)
@@ -247,35 +304,57 @@ func parseGd(p *parser) Formula {
 	return parseLiteral(p, false)
 }
 
-func parseLiteral(p *parser, eff bool) *LiteralNode {
-	lit := new(LiteralNode)
-	if p.accept("(", "not") {
-		lit.Negative = true
+func parseLiteral(p *parser, eff bool) Formula {
+	neg := p.accept("(", "not")
+	if neg {
 		defer p.expect(")")
 	}
 	p.expect("(")
 	defer p.expect(")")
 
-	lit.IsEffect = eff
-	lit.Node = Node{p.Loc()}
+	loc := p.Loc()
 	if p.accept("=") {
-		lit.Predicate = Name{"=", lit.Node.Loc()}
-	} else {
-		lit.Predicate = parseName(p, tokName)
+		return &EqualNode{
+			Node:     Node{loc},
+			Negative: neg,
+			Left:     parseTerm(p),
+			Right:    parseTerm(p),
+			IsEffect: eff,
+		}
 	}
-	lit.Arguments = parseTerms(p)
-	return lit
+	return &LiteralNode{
+		Node:      Node{loc},
+		Predicate: parseName(p, tokName),
+		Negative:  neg,
+		Arguments: parseTerms(p),
+		IsEffect:  eff,
+	}
+}
+
+func parseTerm(p *parser) Term {
+	l := p.Loc()
+	if t, ok := p.acceptToken(tokQname); ok {
+		return Term{Name: Name{t.text, l, t.key}, Variable: true}
+	}
+	if p.peek().typ == tokOpen {
+		return parseFunctionTerm(p)
+	}
+	return Term{Name: parseName(p, tokName)}
 }
 
 func parseTerms(p *parser) (lst []Term) {
 	for {
 		l := p.Loc()
 		if t, ok := p.acceptToken(tokName); ok {
-			lst = append(lst, Term{Name: Name{t.text, l}})
+			lst = append(lst, Term{Name: Name{t.text, l, t.key}})
 			continue
 		}
 		if t, ok := p.acceptToken(tokQname); ok {
-			lst = append(lst, Term{Name: Name{t.text, l}, Variable: true})
+			lst = append(lst, Term{Name: Name{t.text, l, t.key}, Variable: true})
+			continue
+		}
+		if p.peek().typ == tokOpen {
+			lst = append(lst, parseFunctionTerm(p))
 			continue
 		}
 		break
@@ -283,6 +362,15 @@ func parseTerms(p *parser) (lst []Term) {
 	return
 }
 
+// ParseFunctionTerm parses the application of an object fluent as a term.
+func parseFunctionTerm(p *parser) Term {
+	p.expect("(")
+	defer p.expect(")")
+	h := &Fhead{Name: parseName(p, tokName)}
+	h.Arguments = parseTerms(p)
+	return Term{Name: h.Name, Fhead: h}
+}
+
 func parseAndGd(p *parser, nested func(*parser) Formula) Formula {
 	defer p.expect(")")
 	return &AndNode{MultiNode{
@@ -372,10 +460,31 @@ func parseCeffect(p *parser) Formula {
 		return parseForallEffect(p, parseEffect)
 	case p.accept("(", "when"):
 		return parseWhen(p, parseCondEffect)
+	case p.accept("(", "probabilistic"):
+		return parseProbabilistic(p, parseEffect)
 	}
 	return parsePeffect(p)
 }
 
+// ParseProbabilistic parses the probabilities and
+// outcomes of a probabilistic effect.
+func parseProbabilistic(p *parser, nested func(*parser) Formula) Formula {
+	defer p.expect(")")
+	n := &ProbabilisticNode{MultiNode: MultiNode{Node: Node{p.Loc()}}}
+	for {
+		t, ok := p.acceptToken(tokNum)
+		if !ok {
+			break
+		}
+		n.Probabilities = append(n.Probabilities, parseNumber(p, t))
+		n.Formula = append(n.Formula, nested(p))
+	}
+	if len(n.Formula) == 0 {
+		errorf(p, "probabilistic effect without outcomes")
+	}
+	return n
+}
+
 func parseForallEffect(p *parser, nested func(*parser) Formula) Formula {
 	defer p.expect(")")
 	loc := p.Loc()
@@ -398,16 +507,16 @@ func parseWhen(p *parser, nested func(*parser) Formula) Formula {
 }
 
 func parsePeffect(p *parser) Formula {
-	if _, ok := AssignOps[p.peekn(2).text]; ok && p.peek().typ == tokOpen {
+	if _, ok := AssignOps[p.peekn(2).key]; ok && p.peek().typ == tokOpen {
 		return parseAssign(p)
 	}
 	return parseLiteral(p, true)
 }
 
 func parseAssign(p *parser) *AssignNode {
+	a := &AssignNode{Node: Node{p.Loc()}}
 	p.expect("(")
 	defer p.expect(")")
-	a := new(AssignNode)
 	a.Op = parseName(p, tokName)
 	a.Lval = parseFhead(p)
 
@@ -419,19 +528,60 @@ func parseAssign(p *parser) *AssignNode {
 	// 	(<function-symbol> <term>*)
 	// i.e., an f-head
 
+	// With :object-fluents, the value can also
+	// be a constant or a variable.
+	parseAssignValue(p, a)
+	return a
+}
+
+// ParseAssignValue parses the right-hand-side of an assignment.
+func parseAssignValue(p *parser, a *AssignNode) {
+	l := p.Loc()
+	switch t := p.peek(); {
+	case t.typ == tokNum:
+		a.IsNumber = true
+		a.Number = parseNumber(p, p.next())
+	case t.key == "#t":
+		p.next()
+		a.Continuous = true
+		a.IsNumber = true
+		a.Number = "1"
+	case t.typ == tokOpen && p.peekn(2).key == "*":
+		parseContinuousValue(p, a)
+	case t.typ == tokName || t.typ == tokQname:
+		p.next()
+		a.IsTerm = true
+		a.Term = Term{Name: Name{t.text, l, t.key}, Variable: t.typ == tokQname}
+	default:
+		a.Fhead = parseFhead(p)
+	}
+}
+
+// ParseContinuousValue parses the value of a continuous effect,
+// the product of #t and a rate: (* #t rate) or (* rate #t).
+func parseContinuousValue(p *parser, a *AssignNode) {
+	p.expect("(", "*")
+	defer p.expect(")")
+	a.Continuous = true
+	hash := p.accept("#t")
 	if n, ok := p.acceptToken(tokNum); ok {
 		a.IsNumber = true
-		a.Number = n.text
+		a.Number = parseNumber(p, n)
 	} else {
 		a.Fhead = parseFhead(p)
 	}
-	return a
+	if !hash {
+		p.expect("#t")
+	}
 }
 
 func parseCondEffect(p *parser) Formula {
 	if p.accept("(", "and") {
 		return parseAndEffect(p, parsePeffect)
 	}
+	if p.accept("(", "probabilistic") {
+		return parseProbabilistic(p, parseCondEffect)
+	}
 	return parsePeffect(p)
 }
 
@@ -445,6 +595,24 @@ func parseFhead(p *parser) (head Fhead) {
 	return
 }
 
+// ParseNumber returns the text of a number token,
+// removing any repeated leading minus signs.  It is an
+// error if the token has no digits.
+func parseNumber(p *parser, n token) string {
+	if !strings.ContainsAny(n.text, "0123456789") {
+		errorf(p, "malformed number %s", n.text)
+	}
+	if !strings.HasPrefix(n.text, "--") {
+		return n.text
+	}
+	p.lenient(p.opts.AllowRepeatedMinus, p, RepeatedMinus,
+		"repeated minus in number %s", n.text)
+	if strings.Count(n.text, "-")%2 == 1 {
+		return "-" + strings.TrimLeft(n.text, "-")
+	}
+	return strings.TrimLeft(n.text, "-")
+}
+
 func parseProblem(p *parser) *Problem {
 	return &Problem{
 		Name:         parseProbName(p),
@@ -480,28 +648,132 @@ func parseObjsDecl(p *parser) []TypedEntry {
 func parseInit(p *parser) (els []Formula) {
 	p.expect("(", ":init")
 	defer p.expect(")")
+	var facts factTable
 	for p.peek().typ == tokOpen {
-		els = append(els, parseInitEl(p))
+		els = append(els, parseInitEl(p, &facts))
 	}
 	return
 }
 
-func parseInitEl(p *parser) Formula {
+// FactBlock is the number of literals and
+// terms allocated at a time by a factTable.
+const factBlock = 1024
+
+// A factTable allocates the literals of :init facts in blocks, with the
+// arguments of the literals stored contiguously in blocks of terms.
+// Problems can have millions of :init facts, and allocating each fact
+// and its arguments individually is slow and uses a lot of memory.
+//
+// This is not a columnar fact store: each fact is still a complete
+// LiteralNode with its own Location and Terms, and Problem.Init is
+// still a []Formula.  Only the number of allocations is reduced.
+type factTable struct {
+	lits  []LiteralNode
+	terms []Term
+}
+
+// Literal parses a positive literal, allocating it from the table.
+func (t *factTable) literal(p *parser) *LiteralNode {
+	p.expect("(")
+	defer p.expect(")")
+	if len(t.lits) == cap(t.lits) {
+		t.lits = make([]LiteralNode, 0, factBlock)
+	}
+	t.lits = t.lits[:len(t.lits)+1]
+	lit := &t.lits[len(t.lits)-1]
+	lit.Node = Node{p.Loc()}
+	lit.Predicate = parseName(p, tokName)
+
+	start := len(t.terms)
+	for {
+		l := p.Loc()
+		tok := p.peek()
+		if tok.typ != tokName && tok.typ != tokQname {
+			break
+		}
+		p.next()
+		if len(t.terms) == cap(t.terms) {
+			// Move the arguments parsed so far to a new block.
+			n := len(t.terms) - start
+			terms := make([]Term, n, n+factBlock)
+			copy(terms, t.terms[start:])
+			t.terms, start = terms, 0
+		}
+		t.terms = append(t.terms, Term{Name: Name{tok.text, l, tok.key}, Variable: tok.typ == tokQname})
+	}
+	if end := len(t.terms); end > start {
+		lit.Arguments = t.terms[start:end:end]
+	}
+	if p.peek().typ == tokOpen {
+		// Function terms are not allowed in :init, but they
+		// are parsed so that the checker can report them.
+		lit.Arguments = append(lit.Arguments, parseTerms(p)...)
+	}
+	return lit
+}
+
+func parseInitEl(p *parser, facts *factTable) Formula {
 	loc := p.Loc()
 	if p.accept("(", "=") {
-		defer p.expect(")")
-		return &AssignNode{
-			Node:     Node{loc},
-			Op:       Name{"=", p.Loc()},
-			Lval:     parseFhead(p),
-			IsNumber: true,
-			Number:   p.expectType(tokNum).text,
-			IsInit:   true,
-		}
+		return parseInitAssign(p, loc)
+	}
+	// A timed initial literal is distinguished from
+	// the common predicate named at by its time.
+	if p.peekn(2).key == "at" && p.peekn(3).typ == tokNum {
+		return parseTimedInit(p)
+	}
+	if p.peekn(2).typ == tokName && p.peekn(2).key != "not" {
+		return facts.literal(p)
 	}
 	return parseLiteral(p, false)
 }
 
+// ParseInitAssign parses the remainder of
+// a function assignment in the :init section.
+func parseInitAssign(p *parser, loc Location) *AssignNode {
+	defer p.expect(")")
+	a := &AssignNode{
+		Node:   Node{loc},
+		Op:     Name{"=", p.Loc(), "="},
+		Lval:   parseFhead(p),
+		IsInit: true,
+	}
+	parseInitValue(p, a)
+	return a
+}
+
+// ParseInitValue parses the value of an :init assignment: a number or,
+// with :object-fluents, a constant.  Other terms and function values are
+// not allowed, but they are parsed so that the checker can report them.
+func parseInitValue(p *parser, a *AssignNode) {
+	l := p.Loc()
+	switch t := p.peek(); t.typ {
+	case tokNum:
+		a.IsNumber = true
+		a.Number = parseNumber(p, p.next())
+	case tokName, tokQname:
+		p.next()
+		a.IsTerm = true
+		a.Term = Term{Name: Name{t.text, l, t.key}, Variable: t.typ == tokQname}
+	default:
+		a.Fhead = parseFhead(p)
+	}
+}
+
+// ParseTimedInit parses a timed initial literal or fluent.
+func parseTimedInit(p *parser) *TimedNode {
+	p.expect("(", "at")
+	defer p.expect(")")
+	n := &TimedNode{Time: parseNumber(p, p.expectType(tokNum))}
+	n.Location = p.Loc()
+	if p.accept("(", "=") {
+		n.Formula = parseInitAssign(p, n.Location)
+	} else {
+		n.Formula = parseLiteral(p, false)
+	}
+	return n
+}
+
 func parseGoal(p *parser) Formula {
 	p.expect("(", ":goal")
 	defer p.expect(")")
@@ -523,14 +795,13 @@ func parseNamesPlus(p *parser, typ tokenType) []Name {
 func parseNames(p *parser, typ tokenType) (ids []Name) {
 	for t, ok := p.acceptToken(typ); ok; t, ok = p.acceptToken(typ) {
 		l := p.Loc()
-		ids = append(ids, Name{t.text, l})
+		ids = append(ids, Name{t.text, l, t.key})
 	}
 	return
 }
 
 func parseName(p *parser, typ tokenType) Name {
-	return Name{
-		Location: p.Loc(),
-		Str:      p.expectType(typ).text,
-	}
+	l := p.Loc()
+	t := p.expectType(typ)
+	return Name{t.text, l, t.key}
 }
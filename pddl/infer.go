@@ -0,0 +1,383 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// InferTypes rewrites an untyped domain and problem into an equivalent
+// typed domain and problem.  Unary predicates that appear in no effect
+// are used as types: the objects of such a type are the objects for
+// which the predicate is true in the initial state.  Nested sets of
+// objects become sub types, and a predicate is not used as a type if
+// its objects partially overlap those of a type that is used.
+//
+// Each object and constant is given the most specific type that contains
+// it.  Each action parameter that is required by a conjunct of the action
+// precondition to be of a type is given that type, and the conjuncts
+// that are implied by the parameter's type are removed.  Predicates
+// that are no longer referenced by any action or the goal are removed
+// along with their :init facts.  :typing is added to the domain
+// requirements if it is not already required.
+//
+// The types are inferred from the problem, so the resulting domain is
+// only equivalent to the original for this problem.  Both the domain and
+// the problem must have been checked without errors, and they must be
+// re-checked after types are inferred.
+func InferTypes(d *Domain, p *Problem) error {
+	for _, t := range d.Types {
+		if t.Key() != objectTypeName {
+			return fmt.Errorf("%s: domain %s already has types", t.Loc(), d.Name)
+		}
+	}
+	inf := inferTypes(d, p)
+	if len(inf.types) == 0 {
+		return nil
+	}
+	// Types with a super type must be printed before those without.
+	var subs, roots []Type
+	for _, t := range inf.types {
+		typ := Type{TypedEntry: TypedEntry{
+			Name:  t.pred.Name,
+			Types: inf.typeName(t.parent, t.pred.Location),
+		}}
+		if t.parent == nil {
+			roots = append(roots, typ)
+		} else {
+			subs = append(subs, typ)
+		}
+	}
+	d.Types = append(append(d.Types, subs...), roots...)
+
+	retypeObjects(inf, d.Constants)
+	retypeObjects(inf, p.Objects)
+	for _, act := range d.Happenings() {
+		retypeParameters(inf, act)
+	}
+	removeUnusedTypePreds(inf, d, p)
+
+	if !declaresReq(d.Requirements, ":typing") && !declaresReq(d.Requirements, ":adl") {
+		d.Requirements = append(d.Requirements, Name{Str: ":typing", Location: d.Location})
+	}
+	return nil
+}
+
+type (
+	// An inference holds the types that are inferred from
+	// unary inertial predicates.
+	inference struct {
+		// types are the inferred types, in an order such that
+		// each type comes after its parent.
+		types []*inferredType
+
+		// byPred maps a predicate to its inferred type, if any.
+		byPred map[*Predicate]*inferredType
+	}
+
+	// An inferredType is a type that was inferred from a unary inertial predicate.
+	inferredType struct {
+		// pred is the predicate from which the type was inferred.
+		pred *Predicate
+
+		// objs is the set of objects for which the predicate is
+		// initially true.
+		objs map[*TypedEntry]bool
+
+		// parent is the super type, or nil if the super type is object.
+		parent *inferredType
+
+		// depth is the number of ancestors of the type.
+		depth int
+	}
+)
+
+// InferTypes returns the types inferred from the unary inertial
+// predicates of the domain using the initial state of the problem.
+func inferTypes(d *Domain, p *Problem) *inference {
+	// Predicates of timed initial literals change
+	// over time, so they are not inertial.
+	timed := make(map[*Predicate]bool)
+	for _, f := range p.Init {
+		if n, ok := f.(*TimedNode); ok {
+			if lit, ok := n.Formula.(*LiteralNode); ok {
+				timed[lit.Definition] = true
+			}
+		}
+	}
+	var cands []*inferredType
+	byPred := make(map[*Predicate]*inferredType)
+	for i := range d.Predicates {
+		pred := &d.Predicates[i]
+		if len(pred.Parameters) != 1 || pred.PosEffect || pred.NegEffect || timed[pred] ||
+			pred.Key() == objectTypeName {
+			continue
+		}
+		t := &inferredType{pred: pred, objs: make(map[*TypedEntry]bool)}
+		cands = append(cands, t)
+		byPred[pred] = t
+	}
+	for _, f := range p.Init {
+		lit, ok := f.(*LiteralNode)
+		if !ok || lit.Negative || len(lit.Arguments) != 1 {
+			continue
+		}
+		if t := byPred[lit.Definition]; t != nil && lit.Arguments[0].Definition != nil {
+			t.objs[lit.Arguments[0].Definition] = true
+		}
+	}
+
+	// Consider larger sets first so that super
+	// types are accepted before their sub types.
+	sort.Stable(bySize(cands))
+	inf := &inference{byPred: make(map[*Predicate]*inferredType)}
+	for _, t := range cands {
+		if !inf.laminar(t) {
+			continue
+		}
+		t.parent = inf.smallestSuperset(t)
+		if t.parent != nil {
+			t.depth = t.parent.depth + 1
+		}
+		inf.types = append(inf.types, t)
+		inf.byPred[t.pred] = t
+	}
+	return inf
+}
+
+// Laminar returns true if the objects of the type are either disjoint
+// from, or nested with, the objects of each of the accepted types.
+func (inf *inference) laminar(t *inferredType) bool {
+	for _, u := range inf.types {
+		n := 0
+		for o := range t.objs {
+			if u.objs[o] {
+				n++
+			}
+		}
+		if n != 0 && n != len(t.objs) && n != len(u.objs) {
+			return false
+		}
+	}
+	return true
+}
+
+// SmallestSuperset returns the most recently accepted of the smallest
+// accepted types whose objects are a superset of the type's objects,
+// or nil if there is no such type.  Types with no objects are never
+// given a super type.
+func (inf *inference) smallestSuperset(t *inferredType) *inferredType {
+	if len(t.objs) == 0 {
+		return nil
+	}
+	var super *inferredType
+	for _, u := range inf.types {
+		if (super == nil || len(u.objs) <= len(super.objs)) && u.contains(t) {
+			super = u
+		}
+	}
+	return super
+}
+
+// Contains returns true if the objects of t are a subset of those of u.
+func (u *inferredType) contains(t *inferredType) bool {
+	for o := range t.objs {
+		if !u.objs[o] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsA returns true if t is u or a sub type of u.
+func (t *inferredType) isA(u *inferredType) bool {
+	for ; t != nil; t = t.parent {
+		if t == u {
+			return true
+		}
+	}
+	return false
+}
+
+// TypeName returns the type list naming an inferred type, or
+// the empty list if the type is nil, meaning object.
+func (inf *inference) typeName(t *inferredType, l Location) []TypeName {
+	if t == nil {
+		return nil
+	}
+	return []TypeName{{Name: Name{Str: t.pred.Str, Location: l}}}
+}
+
+// RetypeObjects gives each object the deepest inferred type that contains it.
+func retypeObjects(inf *inference, objs []TypedEntry) {
+	for i := range objs {
+		var typ *inferredType
+		for _, t := range inf.types {
+			if t.objs[&objs[i]] && (typ == nil || t.depth >= typ.depth) {
+				typ = t
+			}
+		}
+		objs[i].Types = inf.typeName(typ, objs[i].Location)
+	}
+	sort.Stable(untypedLast(objs))
+}
+
+// RetypeParameters gives each parameter of the action the deepest inferred
+// type required of it by a conjunct of the action precondition, and removes
+// the conjuncts that are implied by the new parameter types.
+func retypeParameters(inf *inference, act *Action) {
+	conjs := topConjuncts(act.Precondition)
+	types := make([]*inferredType, len(act.Parameters))
+	for _, c := range conjs {
+		i, t := inf.paramType(act, c)
+		if t != nil && (types[i] == nil || t.depth > types[i].depth) {
+			types[i] = t
+		}
+	}
+	var keep []Formula
+	for _, c := range conjs {
+		if i, t := inf.paramType(act, c); t != nil && types[i].isA(t) {
+			continue
+		}
+		keep = append(keep, c)
+	}
+	typed := false
+	for i, t := range types {
+		if t != nil {
+			act.Parameters[i].Types = inf.typeName(t, act.Parameters[i].Location)
+			typed = true
+		}
+	}
+	if typed {
+		// Parameters cannot be reordered, so the remaining
+		// parameters are explicitly declared as objects.
+		for i, t := range types {
+			if t == nil {
+				act.Parameters[i].Types = []TypeName{{
+					Name: Name{Str: objectTypeName, Location: act.Parameters[i].Location},
+				}}
+			}
+		}
+	}
+	switch pre := act.Precondition.(type) {
+	case *AndNode:
+		pre.Formula = keep
+	case *LiteralNode:
+		if len(keep) == 0 {
+			act.Precondition = &AndNode{MultiNode{Node: pre.Node}}
+		}
+	}
+}
+
+// TopConjuncts returns the conjuncts of a formula that is either a
+// conjunction or a single literal, and nil for any other formula.
+func topConjuncts(f Formula) []Formula {
+	switch n := f.(type) {
+	case *AndNode:
+		return n.Formula
+	case *LiteralNode:
+		return []Formula{n}
+	}
+	return nil
+}
+
+// ParamType returns the index of an action parameter and the inferred type
+// that is required of it by the formula, if the formula is a positive
+// literal of an inferred type's predicate applied to an action parameter.
+// Otherwise the type is nil.
+func (inf *inference) paramType(act *Action, f Formula) (int, *inferredType) {
+	lit, ok := f.(*LiteralNode)
+	if !ok || lit.Negative || len(lit.Arguments) != 1 || !lit.Arguments[0].Variable {
+		return 0, nil
+	}
+	t := inf.byPred[lit.Definition]
+	if t == nil {
+		return 0, nil
+	}
+	for i := range act.Parameters {
+		if lit.Arguments[0].Definition == &act.Parameters[i] {
+			return i, t
+		}
+	}
+	return 0, nil
+}
+
+// RemoveUnusedTypePreds removes the predicates of inferred types that are
+// no longer referenced by any action or by the goal, along with their
+// :init facts.
+func removeUnusedTypePreds(inf *inference, d *Domain, p *Problem) {
+	used := make(map[*Predicate]bool)
+	mark := func(l *LiteralNode, _ bool) { used[l.Definition] = true }
+	for _, act := range d.Happenings() {
+		if act.Precondition != nil {
+			visitLiterals(act.Precondition, true, mark)
+		}
+		if act.Effect != nil {
+			visitLiterals(act.Effect, true, mark)
+		}
+	}
+	visitLiterals(p.Goal, true, mark)
+
+	unused := func(pred *Predicate) bool {
+		return inf.byPred[pred] != nil && !used[pred]
+	}
+	var init []Formula
+	for _, f := range p.Init {
+		if lit, ok := f.(*LiteralNode); ok && unused(lit.Definition) {
+			continue
+		}
+		init = append(init, f)
+	}
+	p.Init = init
+	var preds []Predicate
+	for i := range d.Predicates {
+		if !unused(&d.Predicates[i]) {
+			preds = append(preds, d.Predicates[i])
+		}
+	}
+	d.Predicates = preds
+}
+
+// DeclaresReq returns true if the requirement is in the list.
+func declaresReq(reqs []Name, req string) bool {
+	for _, r := range reqs {
+		if r.Key() == req {
+			return true
+		}
+	}
+	return false
+}
+
+// BySize implements sort.Interface, sorting inferred types
+// by decreasing number of objects.
+type bySize []*inferredType
+
+func (s bySize) Len() int {
+	return len(s)
+}
+
+func (s bySize) Less(i, j int) bool {
+	return len(s[i].objs) > len(s[j].objs)
+}
+
+func (s bySize) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// UntypedLast implements sort.Interface, sorting a typed list so that
+// the entries without a printed type come last, as required by the
+// typed list syntax.
+type untypedLast []TypedEntry
+
+func (s untypedLast) Len() int {
+	return len(s)
+}
+
+func (s untypedLast) Less(i, j int) bool {
+	return typeString(s[i].Types) != "" && typeString(s[j].Types) == ""
+}
+
+func (s untypedLast) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
@@ -0,0 +1,105 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"bytes"
+	"testing"
+)
+
+const inferDomain = `(define (domain d)
+	(:predicates (truck ?t) (vehicle ?v) (location ?l) (city ?c)
+		(at ?x ?l) (road ?a ?b) (mixed ?x))
+	(:action drive
+		:parameters (?t ?a ?b)
+		:precondition (and (truck ?t) (vehicle ?t) (location ?a) (location ?b)
+			(at ?t ?a) (road ?a ?b))
+		:effect (and (not (at ?t ?a)) (at ?t ?b)))
+	(:action visit
+		:parameters (?x ?c)
+		:precondition (and (mixed ?x) (city ?c))
+		:effect (at ?x ?c)))`
+
+const inferProblem = `(define (problem p) (:domain d)
+	(:objects t1 t2 car a b c)
+	(:init (truck t1) (truck t2) (vehicle t1) (vehicle t2) (vehicle car)
+		(location a) (location b) (location c) (city a)
+		(mixed a) (mixed t1) (at t1 a) (road a b))
+	(:goal (at t1 b)))`
+
+func TestInferTypes(t *testing.T) {
+	d, p := mustCheck(t, inferDomain, inferProblem)
+	if err := InferTypes(d, p); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Re-parse and re-check the printed result.
+	var db, pb bytes.Buffer
+	PrintDomain(&db, d)
+	PrintProblem(&pb, p)
+	d, p = mustCheck(t, db.String(), pb.String())
+
+	for _, typ := range []string{"truck", "vehicle", "location", "city"} {
+		if findType(typ, d.Types) == nil {
+			t.Errorf("expected type %s: %v", typ, d.Types)
+		}
+	}
+	if findType("mixed", d.Types) != nil {
+		t.Errorf("unexpected type mixed")
+	}
+	checkSupers("truck", []string{"truck", "vehicle", "object"})(db.String(), d, t)
+	checkSupers("city", []string{"city", "location", "object"})(db.String(), d, t)
+
+	checkEntryTypes(pb.String(), "t1", []string{"truck"}, p.Objects, t)
+	checkEntryTypes(pb.String(), "car", []string{"vehicle"}, p.Objects, t)
+	checkEntryTypes(pb.String(), "a", []string{"city"}, p.Objects, t)
+	checkEntryTypes(pb.String(), "b", []string{"location"}, p.Objects, t)
+
+	checkActionParamTypes("drive", "?t", []string{"truck"})(db.String(), d, t)
+	checkActionParamTypes("drive", "?a", []string{"location"})(db.String(), d, t)
+	checkActionParamTypes("visit", "?x", []string{"object"})(db.String(), d, t)
+	checkActionParamTypes("visit", "?c", []string{"city"})(db.String(), d, t)
+
+	if findPred("truck", d.Predicates) != nil {
+		t.Errorf("%s\nexpected predicate truck to be removed", db.String())
+	}
+	if findPred("mixed", d.Predicates) == nil {
+		t.Errorf("%s\nexpected predicate mixed to remain", db.String())
+	}
+	if n := len(p.Init); n != 4 {
+		t.Errorf("%s\nexpected 4 init facts, got %d", pb.String(), n)
+	}
+}
+
+func TestInferTypesTimedInit(t *testing.T) {
+	d, p := mustCheck(t, inferDomain, `(define (problem p) (:domain d)
+		(:requirements :timed-initial-literals)
+		(:objects t1 t2 a b)
+		(:init (truck t1) (truck t2) (vehicle t1) (location a)
+			(at 10 (not (truck t1))) (at 5 (location b)) (at t1 a))
+		(:goal (at t1 b)))`)
+	if err := InferTypes(d, p); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var db, pb bytes.Buffer
+	PrintDomain(&db, d)
+	PrintProblem(&pb, p)
+	d, p = mustCheck(t, db.String(), pb.String())
+	for _, typ := range []string{"truck", "location"} {
+		if findType(typ, d.Types) != nil {
+			t.Errorf("%s\nunexpected type %s of a timed initial literal", db.String(), typ)
+		}
+	}
+	if findType("vehicle", d.Types) == nil {
+		t.Errorf("%s\nexpected type vehicle", db.String())
+	}
+}
+
+func TestInferTypesTyped(t *testing.T) {
+	d, p := mustCheck(t,
+		`(define (domain d) (:requirements :typing) (:types t))`,
+		`(define (problem p) (:domain d) (:init) (:goal (and)))`)
+	if err := InferTypes(d, p); err == nil {
+		t.Errorf("expected an error for a typed domain")
+	}
+}
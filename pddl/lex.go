@@ -4,6 +4,7 @@ package pddl
 
 import (
 	"fmt"
+	"io"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -21,11 +22,13 @@ const (
 	tokClose tokenType = ')'
 	tokMinus tokenType = '-'
 	tokEq    tokenType = '='
+	tokStar  tokenType = '*'
 	tokErr   tokenType = iota + 255
 	tokName
 	tokQname
 	tokCname
 	tokNum
+	tokTime
 )
 
 var (
@@ -35,17 +38,32 @@ var (
 		tokClose: "')'",
 		tokMinus: "'-'",
 		tokEq:    "'='",
+		tokStar:  "'*'",
 		tokName:  "name",
 		tokQname: "?name",
 		tokCname: ":name",
 		tokNum:   "number",
+		tokTime:  "#t",
 	}
 
+	// RuneSyms are the symbols of the single-rune tokens.
+	runeSyms = [utf8.RuneSelf]*symbol{
+		'(': {"(", "("},
+		')': {")", ")"},
+		'-': {"-", "-"},
+		'=': {"=", "="},
+		'*': {"*", "*"},
+	}
+
+	// TimeSym is the symbol of #t.
+	timeSym = &symbol{"#t", "#t"}
+
 	runeToks = map[rune]tokenType{
 		'(': tokOpen,
 		')': tokClose,
 		'-': tokMinus,
 		'=': tokEq,
+		'*': tokStar,
 	}
 )
 
@@ -54,9 +72,13 @@ func (t tokenType) String() string {
 }
 
 // Token is a scanned token from a PDDL input.
+//
+// The text and the case-folded key, by which tokens are
+// compared with keywords, are held by an interned symbol,
+// so that tokens are cheap to copy.
 type token struct {
-	typ  tokenType
-	text string
+	typ tokenType
+	*symbol
 }
 
 func (t token) String() string {
@@ -69,46 +91,87 @@ func (t token) String() string {
 	return fmt.Sprintf("%v [%q]", t.typ, t.text)
 }
 
+// LexBufSize is the initial size of a lexer's input buffer.
+const lexBufSize = 4096
+
 // A lexer holds information and performs lexical analysis of a PDDL input.
 type lexer struct {
 	name   string
-	text   string
-	start  int
-	pos    int
+	in     io.Reader
+	err    error
 	lineno int
-	width  int
+
+	// buf is the input that has been read, and buf[start:pos]
+	// is the text of the current token.  The input before the
+	// current token is discarded when more input is read.
+	buf        []byte
+	start, pos int
+
+	// width is the width in bytes of the last rune
+	// that was scanned, or 0 at the end of the input.
+	width int
+
+	// syms interns the text of tokens.
+	syms *symtab
 }
 
-// NewLexer returns a new lexer that returns tokens for the given PDDL string.
-func newLexer(name, text string) *lexer {
+// NewLexer returns a new lexer that returns tokens for the PDDL read from the given io.Reader.
+func newLexer(name string, r io.Reader) *lexer {
 	return &lexer{
 		name:   name,
-		text:   text,
+		in:     r,
 		lineno: 1,
+		buf:    make([]byte, 0, lexBufSize),
+		syms:   newSymtab(),
 	}
 }
 
 // Next consumes and returns the next rune.
-func (l *lexer) next() (r rune) {
-	if l.pos >= len(l.text) {
+func (l *lexer) next() rune {
+	for l.err == nil && (l.pos == len(l.buf) ||
+		l.buf[l.pos] >= utf8.RuneSelf && !utf8.FullRune(l.buf[l.pos:])) {
+		l.fill()
+	}
+	if l.pos == len(l.buf) {
 		l.width = 0
 		return eof
 	}
-	r, l.width = utf8.DecodeRuneInString(l.text[l.pos:])
-	l.pos += l.width
+	r, w := rune(l.buf[l.pos]), 1
+	if r >= utf8.RuneSelf {
+		r, w = utf8.DecodeRune(l.buf[l.pos:])
+	}
+	l.pos += w
+	l.width = w
 	if r == '\n' {
 		l.lineno++
 	}
-	return
+	return r
+}
+
+// Fill reads more input into the buffer, first discarding the
+// input before the current token.  The buffer grows if the
+// current token fills it.
+func (l *lexer) fill() {
+	n := copy(l.buf, l.buf[l.start:])
+	l.buf, l.pos, l.start = l.buf[:n], l.pos-l.start, 0
+	if n == cap(l.buf) {
+		buf := make([]byte, n, 2*cap(l.buf))
+		copy(buf, l.buf)
+		l.buf = buf
+	}
+	m, err := l.in.Read(l.buf[n:cap(l.buf)])
+	l.buf = l.buf[:n+m]
+	l.err = err
 }
 
 // Backup puts the last rune that was scanned back in the scanner buffer so that it will
 // be returned by the next call to next(). Backup can only be called once per call to next.
 func (l *lexer) backup() {
-	if strings.HasPrefix(l.text[l.pos-l.width:l.pos], "\n") {
+	l.pos -= l.width
+	if l.width > 0 && l.buf[l.pos] == '\n' {
 		l.lineno--
 	}
-	l.pos -= l.width
+	l.width = 0
 }
 
 func (l *lexer) peek() rune {
@@ -141,17 +204,18 @@ func (l *lexer) acceptRun(s string) (any bool) {
 	return
 }
 
-// MakeToken returns a token with the given type where the text is that between the start
-// and current positions of the lexer.
+// MakeToken returns a token with the given type where the text is that scanned since
+// the previous token.  The text is interned, so that each distinct text is only allocated once.
 func (l *lexer) makeToken(t tokenType) token {
-	tok := token{text: l.text[l.start:l.pos], typ: t}
+	tok := token{t, l.syms.intern(l.buf[l.start:l.pos])}
 	l.start = l.pos
 	return tok
 }
 
 // Errorf returns a token of type tokErr with the text given by the format.
 func (l *lexer) errorf(format string, args ...interface{}) token {
-	return token{typ: tokErr, text: fmt.Sprintf(format, args...)}
+	text := fmt.Sprintf(format, args...)
+	return token{tokErr, &symbol{text, text}}
 }
 
 // Token returns the next token scanned from the PDDL.
@@ -162,10 +226,18 @@ func (l *lexer) token() token {
 		if r == '-' && (unicode.IsDigit(l.peek()) || l.peek() == '-') {
 			return l.lexNum()
 		}
-		if typ, ok := runeToks[r]; ok {
-			return l.makeToken(typ)
+		// '*' is only allowed on its own, in (* #t rate)
+		if r == '*' && isNameRune(l.peek()) {
+			return l.errorf("unexpected token in input: *%c", l.peek())
+		}
+		switch r {
+		case '(', ')', '-', '=', '*':
+			l.junk()
+			return token{tokenType(r), runeSyms[r]}
 		}
 		switch {
+		case r == eof && l.err != io.EOF:
+			return l.errorf("%s", l.err)
 		case r == eof:
 			return l.makeToken(eof)
 		case unicode.IsSpace(r):
@@ -178,6 +250,8 @@ func (l *lexer) token() token {
 			return l.lexName(tokCname)
 		case unicode.IsLetter(r):
 			return l.lexName(tokName)
+		case r == '#':
+			return l.lexTime()
 		case unicode.IsDigit(r):
 			return l.lexNum()
 		default:
@@ -192,13 +266,32 @@ func (l *lexer) token() token {
 //	underscores (``_"). Case is not significant.
 func (l *lexer) lexName(t tokenType) token {
 	r := l.next()
-	for unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' {
+	for isNameRune(r) {
 		r = l.next()
 	}
 	l.backup()
 	return l.makeToken(t)
 }
 
+// IsNameRune returns true if the rune can appear in a name after its first rune.
+func isNameRune(r rune) bool {
+	if r < utf8.RuneSelf {
+		return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_' || r == '-'
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// LexTime lexes #t, the time elapsed in a
+// PDDL+ continuous effect.  The # character
+// is not allowed anywhere else.
+func (l *lexer) lexTime() token {
+	if !l.accept("tT") || isNameRune(l.peek()) {
+		return l.errorf("unexpected token in input: %s", l.buf[l.start:l.pos])
+	}
+	l.junk()
+	return token{tokTime, timeSym}
+}
+
 func (l *lexer) lexNum() token {
 	l.acceptRun("-")
 	digits := "0123456789"
@@ -213,7 +306,7 @@ func (l *lexer) lexNum() token {
 }
 
 func (l *lexer) lexComment() {
-	for t := l.next(); t != '\n'; t = l.next() {
+	for t := l.next(); t != '\n' && t != eof; t = l.next() {
 	}
 	l.junk()
 }
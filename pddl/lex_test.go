@@ -0,0 +1,84 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// A lexTok is the type and text of an expected token.
+type lexTok struct {
+	typ  tokenType
+	text string
+}
+
+var lexTests = []struct {
+	text string
+	toks []lexTok
+
+	// err is true if the last token is expected
+	// to be an error, after the given tokens.
+	err bool
+}{
+	{"(a ?b :c 1.5)", []lexTok{
+		{typ: tokOpen, text: "("}, {typ: tokName, text: "a"}, {typ: tokQname, text: "?b"},
+		{typ: tokCname, text: ":c"}, {typ: tokNum, text: "1.5"}, {typ: tokClose, text: ")"},
+	}, false},
+	{"(* #t 2)", []lexTok{
+		{typ: tokOpen, text: "("}, {typ: tokStar, text: "*"}, {typ: tokTime, text: "#t"},
+		{typ: tokNum, text: "2"}, {typ: tokClose, text: ")"},
+	}, false},
+	{"(* 2 #T)", []lexTok{
+		{typ: tokOpen, text: "("}, {typ: tokStar, text: "*"}, {typ: tokNum, text: "2"},
+		{typ: tokTime, text: "#t"}, {typ: tokClose, text: ")"},
+	}, false},
+	{"#p", nil, true},
+	{"#", nil, true},
+	{"#tt", nil, true},
+	{"#t-1", nil, true},
+	{"(domain *dom)", []lexTok{{typ: tokOpen, text: "("}, {typ: tokName, text: "domain"}}, true},
+	{"*a", nil, true},
+	{"a*", []lexTok{{typ: tokName, text: "a"}, {typ: tokStar, text: "*"}}, false},
+}
+
+func TestLex(t *testing.T) {
+	for _, test := range lexTests {
+		l := newLexer("", strings.NewReader(test.text))
+		for i, want := range test.toks {
+			if tok := l.token(); tok.typ != want.typ || tok.text != want.text {
+				t.Errorf("%q: token %d: expected %v, got %v", test.text, i, want, tok)
+			}
+		}
+		switch tok := l.token(); {
+		case test.err && tok.typ != tokErr:
+			t.Errorf("%q: expected an error, got %v", test.text, tok)
+		case !test.err && tok.typ != tokEof:
+			t.Errorf("%q: expected the end of input, got %v", test.text, tok)
+		}
+	}
+}
+
+// TestLexRefill tests tokens that span the reads
+// that refill and grow the lexer's input buffer.
+func TestLexRefill(t *testing.T) {
+	long := strings.Repeat("n", 3*lexBufSize)
+	text := "(é-name " + long + "\n?x -1.5e-3)"
+	want := []lexTok{
+		{tokOpen, "("}, {tokName, "é-name"}, {tokName, long},
+		{tokQname, "?x"}, {tokNum, "-1.5e-3"}, {tokClose, ")"},
+	}
+	l := newLexer("", iotest.OneByteReader(strings.NewReader(text)))
+	for i, w := range want {
+		if tok := l.token(); tok.typ != w.typ || tok.text != w.text {
+			t.Fatalf("token %d: expected %v [%.10q], got %v", i, w.typ, w.text, tok)
+		}
+	}
+	if tok := l.token(); tok.typ != tokEof {
+		t.Errorf("expected the end of input, got %v", tok)
+	}
+	if l.lineno != 2 {
+		t.Errorf("expected to end on line 2, got %d", l.lineno)
+	}
+}
@@ -0,0 +1,272 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"fmt"
+)
+
+// The kinds of warnings reported by Lint.
+const (
+	// UnusedPredicate warns of a predicate that is not used
+	// by any action, nor by the :init or :goal of the problem.
+	UnusedPredicate = "unused-predicate"
+
+	// UnreachablePredicate warns of a predicate that is required
+	// by an action but is never true: it is not added by any effect
+	// and it is not in the problem's :init.
+	UnreachablePredicate = "unreachable-predicate"
+
+	// UnusedParameter warns of an action parameter that is not
+	// referenced by the precondition or effect of its action.
+	UnusedParameter = "unused-parameter"
+
+	// UnusedRequirement warns of a declared requirement that
+	// is not needed by the domain or problem.
+	UnusedRequirement = "unused-requirement"
+
+	// EmptyType warns of a type with no objects in a problem.
+	EmptyType = "empty-type"
+
+	// AddDelete warns of an effect that both adds and deletes
+	// the same literal.
+	AddDelete = "add-delete"
+
+	// ContradictoryPrecondition warns of a precondition that
+	// requires a literal to be both true and false.
+	ContradictoryPrecondition = "contradictory-precondition"
+
+	// DisjointEquality warns of an equality between terms with
+	// disjoint types, which can never be true.
+	DisjointEquality = "disjoint-equality"
+)
+
+// A Warning is a possible problem in a PDDL file that is not a semantic error.
+type Warning struct {
+	// Location is the location of the cause of the warning.
+	Location
+
+	// Kind is the kind of the warning, one of the warning
+	// constants such as UnusedPredicate.
+	Kind string
+
+	// msg is the warning's message.
+	msg string
+}
+
+func (w Warning) String() string {
+	return w.Location.String() + ": " + w.msg + " [" + w.Kind + "]"
+}
+
+// Lint returns a slice of warnings about the domain and problem.
+//
+// If the problem is nil then only the domain is linted.  The domain must not
+// be nil.  The domain and problem must have been checked without errors.
+func Lint(d *Domain, p *Problem) []Warning {
+	var ws warnings
+	lintPredicates(d, p, &ws)
+	for _, act := range d.Happenings() {
+		lintAction(d.Types, act, &ws)
+	}
+	used := usedReqs(d, p)
+	lintReqs(d.Requirements, used, &ws)
+	if p == nil {
+		return ws
+	}
+	lintReqs(p.Requirements, used, &ws)
+	lintEqualities(d.Types, p.Goal, &ws)
+	for _, t := range d.Types {
+		if t.Location.Line != 0 && len(t.Domain) == 0 {
+			ws.add(t, EmptyType, "type %s has no objects", t)
+		}
+	}
+	return ws
+}
+
+func lintPredicates(d *Domain, p *Problem, ws *warnings) {
+	used := make(map[*Predicate]bool)
+	required := make(map[*Predicate]bool)
+	for _, act := range d.Happenings() {
+		for _, pred := range act.Writes() {
+			used[pred] = true
+		}
+		act.visitConds(func(l *LiteralNode, pos bool) {
+			used[l.Definition] = true
+			if pos {
+				required[l.Definition] = true
+			}
+		})
+	}
+	initial := make(map[*Predicate]bool)
+	if p != nil {
+		for _, f := range p.Init {
+			if t, ok := f.(*TimedNode); ok {
+				f = t.Formula
+			}
+			if lit, ok := f.(*LiteralNode); ok {
+				used[lit.Definition] = true
+				initial[lit.Definition] = initial[lit.Definition] || !lit.Negative
+			}
+		}
+		visitLiterals(p.Goal, true, func(l *LiteralNode, _ bool) {
+			used[l.Definition] = true
+		})
+	}
+	for i := range d.Predicates {
+		pred := &d.Predicates[i]
+		switch {
+		case !used[pred]:
+			ws.add(pred, UnusedPredicate, "predicate %s is never used", pred)
+		case p != nil && required[pred] && !pred.PosEffect && !initial[pred]:
+			ws.add(pred, UnreachablePredicate,
+				"predicate %s is required but it is never true", pred)
+		}
+	}
+}
+
+func lintAction(types []Type, act *Action, ws *warnings) {
+	refd := make(map[*TypedEntry]bool)
+	for _, f := range []Formula{act.Precondition, act.Effect} {
+		if f == nil {
+			continue
+		}
+		walkFormula(f, func(f Formula) {
+			for _, t := range formulaTerms(f) {
+				refd[t.Definition] = true
+			}
+		})
+	}
+	for _, f := range []Formula{act.Precondition, act.Effect} {
+		if f != nil {
+			lintEqualities(types, f, ws)
+		}
+	}
+	for i := range act.Parameters {
+		if parm := &act.Parameters[i]; !refd[parm] {
+			ws.add(parm, UnusedParameter, "parameter %s of action %s is never used", parm, act)
+		}
+	}
+
+	for _, c := range topConjuncts(act.Effect) {
+		lit, ok := c.(*LiteralNode)
+		if !ok || lit.Negative {
+			continue
+		}
+		for _, other := range topConjuncts(act.Effect) {
+			if l, ok := other.(*LiteralNode); ok && l.Negative && sameLiteral(lit, l) {
+				ws.add(lit, AddDelete, "action %s both adds and deletes %s", act, literalString(lit))
+				break
+			}
+		}
+	}
+
+	for _, c := range topConjuncts(act.Precondition) {
+		lit, ok := c.(*LiteralNode)
+		if !ok || lit.Negative {
+			continue
+		}
+		for _, other := range topConjuncts(act.Precondition) {
+			n, ok := other.(*NotNode)
+			if !ok {
+				continue
+			}
+			if l, ok := n.Formula.(*LiteralNode); ok && !l.Negative && sameLiteral(lit, l) {
+				ws.add(lit, ContradictoryPrecondition,
+					"action %s requires %s to be both true and false", act, literalString(lit))
+				break
+			}
+		}
+	}
+}
+
+// LintEqualities adds a warning for each equality in the formula
+// that compares terms with disjoint types.
+func lintEqualities(types []Type, f Formula, ws *warnings) {
+	walkFormula(f, func(f Formula) {
+		eq, ok := f.(*EqualNode)
+		if !ok || eq.Left.Definition == nil || eq.Right.Definition == nil {
+			return
+		}
+		if disjointTypes(types, eq.Left.Definition.Types, eq.Right.Definition.Types) {
+			ws.add(eq, DisjointEquality, "%s [type %s] and %s [type %s] can never be equal",
+				eq.Left, typeString(eq.Left.Definition.Types),
+				eq.Right, typeString(eq.Right.Definition.Types))
+		}
+	})
+}
+
+// FormulaTerms returns the terms that appear directly in a formula node.
+func formulaTerms(f Formula) []Term {
+	switch n := f.(type) {
+	case *LiteralNode:
+		return flatTerms(nil, n.Arguments)
+	case *EqualNode:
+		return flatTerms(nil, []Term{n.Left, n.Right})
+	case *AssignNode:
+		terms := flatTerms(nil, n.Lval.Arguments)
+		switch {
+		case n.IsTerm:
+			terms = append(terms, n.Term)
+		case !n.IsNumber:
+			terms = flatTerms(terms, n.Fhead.Arguments)
+		}
+		return terms
+	}
+	return nil
+}
+
+// FlatTerms appends the terms to a slice, replacing each
+// function term by the terms of its arguments.
+func flatTerms(lst, terms []Term) []Term {
+	for _, t := range terms {
+		if t.Fhead != nil {
+			lst = flatTerms(lst, t.Fhead.Arguments)
+			continue
+		}
+		lst = append(lst, t)
+	}
+	return lst
+}
+
+// SameLiteral returns true if the two literals have the same
+// predicate and arguments, ignoring their signs.  Literals
+// with function terms are never the same, because the
+// values of the functions are unknown.
+func sameLiteral(a, b *LiteralNode) bool {
+	if a.Definition != b.Definition || len(a.Arguments) != len(b.Arguments) {
+		return false
+	}
+	for i := range a.Arguments {
+		if a.Arguments[i].Fhead != nil || b.Arguments[i].Fhead != nil ||
+			a.Arguments[i].Definition != b.Arguments[i].Definition {
+			return false
+		}
+	}
+	return true
+}
+
+// LiteralString returns a string representation of a positive literal.
+func literalString(lit *LiteralNode) string {
+	s := "(" + lit.Predicate.Str
+	for _, t := range lit.Arguments {
+		s += " " + t.String()
+	}
+	return s + ")"
+}
+
+func lintReqs(reqs []Name, used map[string]bool, ws *warnings) {
+	for _, r := range reqs {
+		req := r.Key()
+		if !used[req] {
+			ws.add(r, UnusedRequirement, "requirement %s is not needed", r)
+		}
+	}
+}
+
+// Warnings wraps a slice of warnings.
+type warnings []Warning
+
+// Add adds a Warning to the slice.
+func (ws *warnings) add(l Locer, kind, f string, vs ...interface{}) {
+	*ws = append(*ws, Warning{l.Loc(), kind, fmt.Sprintf(f, vs...)})
+}
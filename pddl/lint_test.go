@@ -0,0 +1,124 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import "testing"
+
+var lintTests = []struct {
+	domain, problem string
+
+	// kind is the kind of warning that is expected,
+	// or the empty string if no warning is expected.
+	kind string
+}{
+	{`(define (domain d) (:predicates (p))
+		(:action a :parameters () :effect (p)))`, "", ""},
+
+	// unused-predicate
+	{`(define (domain d) (:predicates (p) (q))
+		(:action a :parameters () :effect (p)))`, "", UnusedPredicate},
+	{`(define (domain d) (:predicates (p) (q))
+		(:action a :parameters () :effect (p)))`,
+		`(define (problem x) (:domain d) (:init (q)) (:goal (and)))`, ""},
+	{`(define (domain d) (:predicates (p) (q))
+		(:action a :parameters () :effect (p)))`,
+		`(define (problem x) (:domain d) (:init) (:goal (q)))`, ""},
+
+	// unreachable-predicate
+	{`(define (domain d) (:predicates (p) (q))
+		(:action a :parameters () :precondition (q) :effect (p)))`,
+		`(define (problem x) (:domain d) (:init) (:goal (p)))`, UnreachablePredicate},
+	{`(define (domain d) (:predicates (p) (q))
+		(:action a :parameters () :precondition (q) :effect (p)))`,
+		`(define (problem x) (:domain d) (:init (q)) (:goal (p)))`, ""},
+	{`(define (domain d) (:predicates (p) (q))
+		(:action a :parameters () :precondition (q) :effect (p)))`, "", ""},
+
+	// unused-parameter
+	{`(define (domain d) (:predicates (p ?x))
+		(:action a :parameters (?x ?y) :effect (p ?x)))`, "", UnusedParameter},
+	{`(define (domain d) (:predicates (p ?x) (q ?x))
+		(:action a :parameters (?x ?y) :precondition (q ?y) :effect (p ?x)))`, "", ""},
+	{`(define (domain d) (:requirements :adl) (:predicates (p ?x))
+		(:action a :parameters (?x) :effect (forall (?x) (p ?x))))`, "", UnusedParameter},
+	{`(define (domain d) (:requirements :action-costs) (:predicates (p))
+		(:functions (total-cost) (f ?x))
+		(:action a :parameters (?x) :effect (and (p) (increase total-cost (f ?x)))))`, "", ""},
+
+	// unused-requirement
+	{`(define (domain d) (:requirements :typing) (:predicates (p))
+		(:action a :parameters () :effect (p)))`, "", UnusedRequirement},
+	{`(define (domain d) (:requirements :strips :typing) (:types t) (:predicates (p ?x - t))
+		(:action a :parameters (?x - t) :effect (p ?x)))`, "", ""},
+	{`(define (domain d) (:requirements :adl) (:predicates (p))
+		(:action a :parameters () :effect (p)))`, "", UnusedRequirement},
+	{`(define (domain d) (:requirements :adl) (:predicates (p))
+		(:action a :parameters () :precondition (not (p)) :effect (p)))`, "", ""},
+	{`(define (domain d) (:requirements :quantified-preconditions) (:predicates (p ?x) (q))
+		(:action a :parameters () :precondition (exists (?x) (p ?x)) :effect (q)))`, "", ""},
+	{`(define (domain d) (:predicates (p))
+		(:action a :parameters () :effect (p)))`,
+		`(define (problem x) (:domain d) (:requirements :negative-preconditions)
+			(:init) (:goal (p)))`, UnusedRequirement},
+
+	// empty-type
+	{`(define (domain d) (:requirements :typing) (:types t) (:predicates (p ?x - t))
+		(:action a :parameters (?x - t) :effect (p ?x)))`, "", ""},
+	{`(define (domain d) (:requirements :typing) (:types t) (:predicates (p ?x - t))
+		(:action a :parameters (?x - t) :effect (p ?x)))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and)))`, EmptyType},
+	{`(define (domain d) (:requirements :typing) (:types t) (:predicates (p ?x - t))
+		(:action a :parameters (?x - t) :effect (p ?x)))`,
+		`(define (problem x) (:domain d) (:objects o - t) (:init) (:goal (and)))`, ""},
+
+	// add-delete
+	{`(define (domain d) (:predicates (p ?x))
+		(:action a :parameters (?x) :effect (and (p ?x) (not (p ?x)))))`, "", AddDelete},
+	{`(define (domain d) (:predicates (p ?x ?y))
+		(:action a :parameters (?x ?y) :effect (and (p ?x ?y) (not (p ?y ?x)))))`, "", ""},
+
+	// contradictory-precondition
+	{`(define (domain d) (:requirements :negative-preconditions) (:predicates (p ?x) (q))
+		(:action a :parameters (?x) :precondition (and (p ?x) (not (p ?x))) :effect (q)))`,
+		"", ContradictoryPrecondition},
+	{`(define (domain d) (:requirements :negative-preconditions) (:predicates (p ?x) (q))
+		(:action a :parameters (?x ?y) :precondition (and (p ?x) (not (p ?y))) :effect (q)))`,
+		"", ""},
+
+	// disjoint-equality
+	{`(define (domain d) (:requirements :typing :equality) (:types s t) (:predicates (p))
+		(:action a :parameters (?x - s ?y - t) :precondition (= ?x ?y) :effect (p)))`,
+		"", DisjointEquality},
+	{`(define (domain d) (:requirements :typing :equality) (:types s - t t) (:predicates (p))
+		(:action a :parameters (?x - s ?y - t) :precondition (= ?x ?y) :effect (p)))`,
+		"", ""},
+	{`(define (domain d) (:requirements :typing :equality) (:types s t) (:predicates (p))
+		(:action a :parameters (?x - s ?y) :precondition (= ?x ?y) :effect (p)))`,
+		"", ""},
+	{`(define (domain d) (:requirements :typing :equality) (:types u - (either s t) s t) (:predicates (p))
+		(:action a :parameters (?x - s ?y - t) :precondition (= ?x ?y) :effect (p)))`,
+		"", ""},
+}
+
+func TestLint(t *testing.T) {
+	for _, test := range lintTests {
+		d, p := mustCheck(t, test.domain, test.problem)
+		ws := Lint(d, p)
+		switch {
+		case test.kind == "" && len(ws) > 0:
+			t.Errorf("%s\n%s\nunexpected warning: %s", test.domain, test.problem, ws[0])
+		case test.kind != "" && !hasWarning(ws, test.kind):
+			t.Errorf("%s\n%s\nexpected a %s warning, got %v",
+				test.domain, test.problem, test.kind, ws)
+		}
+	}
+}
+
+func hasWarning(ws []Warning, kind string) bool {
+	for _, w := range ws {
+		if w.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+)
+
+// Open opens a PDDL file for reading.  Files compressed with gzip or
+// bzip2 are decompressed transparently.  The compression is detected
+// by a .gz or .bz2 extension, or by the magic bytes at the start of
+// the file.
+func Open(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	in := bufio.NewReader(f)
+	magic, _ := in.Peek(len(bzip2Magic))
+	switch {
+	case strings.HasSuffix(path, ".gz") || bytes.HasPrefix(magic, gzipMagic):
+		z, err := gzip.NewReader(in)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		return readCloser{z, []io.Closer{z, f}}, nil
+	case strings.HasSuffix(path, ".bz2") || bytes.HasPrefix(magic, bzip2Magic):
+		return readCloser{bzip2.NewReader(in), []io.Closer{f}}, nil
+	}
+	return readCloser{in, []io.Closer{f}}, nil
+}
+
+// A readCloser is a Reader that closes
+// each of its closers in order.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r readCloser) Close() error {
+	var err error
+	for _, c := range r.closers {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const openTestProblem = `(define (problem p) (:domain d) (:init) (:goal (and)))`
+
+// openTestBzip2 is openTestProblem compressed with bzip2.
+var openTestBzip2 = []byte{
+	0x42, 0x5a, 0x68, 0x39, 0x31, 0x41, 0x59, 0x26, 0x53, 0x59, 0x2e, 0xa5,
+	0x6a, 0x82, 0x00, 0x00, 0x05, 0x99, 0x80, 0x40, 0x60, 0x00, 0x10, 0x37,
+	0xa7, 0xd4, 0x00, 0x20, 0x00, 0x41, 0x13, 0x26, 0x20, 0xf5, 0x34, 0x64,
+	0x29, 0xa6, 0x46, 0x26, 0x26, 0x22, 0x58, 0x66, 0x21, 0x88, 0x98, 0xfd,
+	0x5c, 0x52, 0x3d, 0x59, 0xe5, 0x0f, 0x19, 0xb8, 0x47, 0x01, 0x79, 0xd4,
+	0x7b, 0xf5, 0xb7, 0x2a, 0x65, 0x34, 0x22, 0x29, 0xfe, 0x2e, 0xe4, 0x8a,
+	0x70, 0xa1, 0x20, 0x5d, 0x4a, 0xd5, 0x04,
+}
+
+func TestOpen(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte(openTestProblem))
+	w.Close()
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"plain.pddl":       []byte(openTestProblem),
+		"p.pddl.gz":        gz.Bytes(),
+		"gzip-magic.pddl":  gz.Bytes(),
+		"p.pddl.bz2":       openTestBzip2,
+		"bzip2-magic.pddl": openTestBzip2,
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0666); err != nil {
+			t.Fatal(err)
+		}
+		r, err := Open(path)
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		ast, err := Parse(path, r)
+		r.Close()
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if p, ok := ast.(*Problem); !ok || p.Name.Str != "p" {
+			t.Errorf("%s: expected problem p, got %v", name, ast)
+		}
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	const input = `(define (domain d) (:predicates (p)))
+		; a comment between defines
+		(define (problem p1) (:domain d) (:init) (:goal (p)))
+		(define (problem p2) (:domain d) (:init) (:goal (p)))`
+	asts, err := ParseAll("", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse error: %s", err)
+	}
+	if len(asts) != 3 {
+		t.Fatalf("expected 3 defines, got %d", len(asts))
+	}
+	if _, ok := asts[0].(*Domain); !ok {
+		t.Errorf("expected a domain, got %T", asts[0])
+	}
+	for i, name := range []string{"p1", "p2"} {
+		if p, ok := asts[i+1].(*Problem); !ok || p.Name.Str != name {
+			t.Errorf("expected problem %s, got %v", name, asts[i+1])
+		}
+	}
+
+	if asts, err := ParseAll("", strings.NewReader("")); err != nil || len(asts) != 0 {
+		t.Errorf("expected no defines and no error for empty input, got %v, %v", asts, err)
+	}
+	if _, err := ParseAll("", strings.NewReader(input+"(define")); err == nil {
+		t.Errorf("expected an error for a truncated define")
+	}
+}
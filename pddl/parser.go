@@ -4,36 +4,121 @@ package pddl
 
 import (
 	"io"
-	"io/ioutil"
+)
+
+// The kinds of warnings reported by ParseWithOptions.
+const (
+	// EmptyTypedNames warns of a type in a typed list
+	// that is not preceded by any names.
+	EmptyTypedNames = "empty-typed-names"
+
+	// EitherSupertype warns of an either type used
+	// as a supertype in a :types definition.
+	EitherSupertype = "either-supertype"
+
+	// MissingParameters warns of an action definition
+	// without a :parameters section.
+	MissingParameters = "missing-parameters"
+
+	// RepeatedMinus warns of a number with more than
+	// one leading minus sign, such as --5.
+	RepeatedMinus = "repeated-minus"
+)
+
+// ParseOptions are the leniencies that the parser allows
+// beyond the PDDL BNF.  Each allowed leniency is reported
+// as a Warning, and each disallowed one is a parse error.
+type ParseOptions struct {
+	// AllowEmptyTypedNames allows a type with no names
+	// before it in a typed list, such as (:objects - t).
+	AllowEmptyTypedNames bool
+
+	// AllowEitherSupertypes allows either types as
+	// supertypes in a :types definition.
+	AllowEitherSupertypes bool
+
+	// AllowMissingParameters allows an action definition
+	// without a :parameters section.
+	AllowMissingParameters bool
+
+	// AllowRepeatedMinus allows numbers with more than one
+	// leading minus sign, such as --5.
+	AllowRepeatedMinus bool
+}
+
+var (
+	// StrictDialect allows no leniencies.
+	StrictDialect = ParseOptions{}
+
+	// IPCDialect allows the leniencies needed
+	// to parse the IPC benchmark domains.
+	IPCDialect = ParseOptions{
+		AllowEmptyTypedNames:   true,
+		AllowEitherSupertypes:  true,
+		AllowMissingParameters: true,
+		AllowRepeatedMinus:     true,
+	}
+
+	// Dialects maps the names of the
+	// dialects to their parse options.
+	Dialects = map[string]ParseOptions{
+		"strict": StrictDialect,
+		"ipc":    IPCDialect,
+	}
 )
 
 // A parser parses PDDL.
 type parser struct {
-	lex    *lexer
-	peeks  [2]token
-	npeeks int
+	lex *lexer
+
+	// peeks is a ring buffer of the npeeks tokens, beginning
+	// at index first, that have been peeked but not consumed.
+	// Tokens are not shifted when one is consumed.
+	peeks         [3]token
+	first, npeeks int
+
+	opts     ParseOptions
+	warnings warnings
+
+	// typesDef is true while parsing a :types definition.
+	typesDef bool
 }
 
 // NewParser returns a new parser that parses from the given io.Reader.
-func newParser(file string, r io.Reader) (*parser, error) {
-	text, err := ioutil.ReadAll(r)
-	if err != nil {
-		return nil, err
+func newParser(file string, r io.Reader, opts ParseOptions) *parser {
+	return &parser{lex: newLexer(file, r), opts: opts}
+}
+
+// Lenient adds a warning of the given kind if the leniency is
+// allowed, otherwise it panics with a parse error.
+func (p *parser) lenient(allowed bool, l Locer, kind, f string, vls ...interface{}) {
+	if !allowed {
+		errorf(l, f+" is not allowed", vls...)
 	}
-	return &parser{lex: newLexer(file, string(text))}, nil
+	p.warnings.add(l, kind, f, vls...)
 }
 
 // Next returns the next lexical token from the parser.
 func (p *parser) next() token {
 	if p.npeeks == 0 {
-		return p.lex.token()
+		return p.lexToken()
 	}
-	t := p.peeks[0]
-	copy(p.peeks[:], p.peeks[1:])
+	t := p.peeks[p.first]
+	p.first = (p.first + 1) % len(p.peeks)
 	p.npeeks--
 	return t
 }
 
+// LexToken returns the next token from the lexer,
+// panicking with a parse error on a lexical error.
+func (p *parser) lexToken() token {
+	t := p.lex.token()
+	if t.typ == tokErr {
+		errorf(p, "%s", t.text)
+	}
+	return t
+}
+
 func (p *parser) Loc() Location {
 	return Location{p.lex.name, p.lex.lineno}
 }
@@ -44,9 +129,9 @@ func (p *parser) peekn(n int) token {
 		panic("Too much peeking in the parser")
 	}
 	for ; p.npeeks < n; p.npeeks++ {
-		p.peeks[p.npeeks] = p.lex.token()
+		p.peeks[(p.first+p.npeeks)%len(p.peeks)] = p.lexToken()
 	}
-	return p.peeks[n-1]
+	return p.peeks[(p.first+n-1)%len(p.peeks)]
 }
 
 func (p *parser) peek() token {
@@ -73,7 +158,7 @@ func (p *parser) accept(texts ...string) bool {
 		panic("too many peeks in accept")
 	}
 	for i := range texts {
-		if p.peekn(i+1).text != texts[i] {
+		if p.peekn(i+1).key != texts[i] {
 			return false
 		}
 	}
@@ -91,7 +176,7 @@ func (p *parser) expectType(typ tokenType) token {
 
 func (p *parser) expectText(text string) token {
 	t := p.next()
-	if t.text != text {
+	if t.key != text {
 		errorf(p, "expected %s, got %s", text, t.text)
 	}
 	return t
@@ -104,7 +189,7 @@ func (p *parser) expect(vls ...string) {
 	}
 	for i := range vls {
 		t := p.next()
-		if t.text != vls[i] {
+		if t.key != vls[i] {
 			errorf(p, "expected %s, got %s", vls[i], t)
 		}
 	}
@@ -0,0 +1,268 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var dialectTests = []struct {
+	pddl string
+
+	// kind is the kind of warning that is expected from the
+	// IPCDialect and the cause of an error from the
+	// StrictDialect, or the empty string if neither is expected.
+	kind string
+}{
+	{`(define (domain d) (:types t) (:constants c - t))`, ""},
+	{`(define (domain d) (:types t) (:constants - t))`, EmptyTypedNames},
+	{`(define (domain d) (:types s t) (:constants c - (either s t)))`, ""},
+	{`(define (domain d) (:types u - (either s t) s t))`, EitherSupertype},
+	{`(define (domain d) (:predicates (p)) (:action a :parameters () :effect (p)))`, ""},
+	{`(define (domain d) (:predicates (p)) (:action a :effect (p)))`, MissingParameters},
+	{`(define (problem x) (:domain d) (:init (= (f) -5)) (:goal (and)))`, ""},
+	{`(define (problem x) (:domain d) (:init (= (f) --5)) (:goal (and)))`, RepeatedMinus},
+}
+
+func TestDialects(t *testing.T) {
+	for _, test := range dialectTests {
+		_, ws, err := ParseWithOptions("", strings.NewReader(test.pddl), IPCDialect)
+		switch {
+		case err != nil:
+			t.Errorf("%s\nunexpected IPC dialect error: %s", test.pddl, err)
+		case test.kind == "" && len(ws) > 0:
+			t.Errorf("%s\nunexpected warning: %s", test.pddl, ws[0])
+		case test.kind != "" && !hasWarning(ws, test.kind):
+			t.Errorf("%s\nexpected a %s warning, got %v", test.pddl, test.kind, ws)
+		}
+
+		_, ws, err = ParseWithOptions("", strings.NewReader(test.pddl), StrictDialect)
+		switch {
+		case test.kind == "" && err != nil:
+			t.Errorf("%s\nunexpected strict dialect error: %s", test.pddl, err)
+		case test.kind != "" && err == nil:
+			t.Errorf("%s\nexpected a strict dialect error", test.pddl)
+		case len(ws) > 0:
+			t.Errorf("%s\nunexpected strict dialect warning: %s", test.pddl, ws[0])
+		}
+	}
+}
+
+func TestRepeatedMinus(t *testing.T) {
+	for _, test := range []struct{ num, want string }{
+		{"--5", "5"}, {"---5", "-5"}, {"-5", "-5"},
+	} {
+		pddl := `(define (problem x) (:domain d) (:init (= (f) ` + test.num + `)) (:goal (and)))`
+		ast, err := Parse("", strings.NewReader(pddl))
+		if err != nil {
+			t.Fatalf("%s\nparse error: %s", pddl, err)
+		}
+		if n := ast.(*Problem).Init[0].(*AssignNode).Number; n != test.want {
+			t.Errorf("expected %s to parse as %s, got %s", test.num, test.want, n)
+		}
+	}
+}
+
+func TestMalformedNumber(t *testing.T) {
+	for _, num := range []string{"--", "---", "--.", "--e"} {
+		pddl := `(define (problem x) (:domain d) (:init (= (f) ` + num + `)) (:goal (and)))`
+		if _, _, err := ParseWithOptions("", strings.NewReader(pddl), IPCDialect); err == nil {
+			t.Errorf("%s\nexpected a parse error", pddl)
+		}
+	}
+}
+
+func TestInitContinuousValue(t *testing.T) {
+	for _, val := range []string{"#t", "(* #t 1)"} {
+		pddl := `(define (problem x) (:domain d) (:init (= (f) ` + val + `)) (:goal (and)))`
+		if _, err := Parse("", strings.NewReader(pddl)); err == nil {
+			t.Errorf("%s\nexpected a parse error", pddl)
+		}
+	}
+}
+
+func TestNameKey(t *testing.T) {
+	const pddl = `(define (domain D) (:predicates (Pred ?X)))`
+	ast, err := Parse("", strings.NewReader(pddl))
+	if err != nil {
+		t.Fatalf("%s\nparse error: %s", pddl, err)
+	}
+	d := ast.(*Domain)
+	pred := d.Predicates[0]
+	for _, n := range []Name{d.Name, pred.Name, pred.Parameters[0].Name} {
+		if n.key == "" || n.key != strings.ToLower(n.Str) {
+			t.Errorf("expected the key of %s to be %q, got %q", n, strings.ToLower(n.Str), n.key)
+		}
+	}
+	if n := (Name{Str: "Object"}); n.Key() != "object" {
+		t.Errorf("expected the key of an unparsed name %s to be %q, got %q", n, "object", n.Key())
+	}
+}
+
+func TestTimedInit(t *testing.T) {
+	const pddl = `(define (problem x) (:domain d)
+		(:init (at a b) (at 10 (at a b)) (at 2.5 (not (p))) (at 0 (= (f a) 3)))
+		(:goal (and)))`
+	ast, err := Parse("", strings.NewReader(pddl))
+	if err != nil {
+		t.Fatalf("%s\nparse error: %s", pddl, err)
+	}
+	var b bytes.Buffer
+	for _, f := range ast.(*Problem).Init {
+		f.print(&b, "")
+		b.WriteString("\n")
+	}
+	want := "(at a b)\n(at 10 (at a b))\n(at 2.5 (not (p)))\n(at 0 (= (f a) 3))\n"
+	if b.String() != want {
+		t.Errorf("%s\nexpected :init\n%s\ngot\n%s", pddl, want, b.String())
+	}
+}
+
+func TestPrintProcesses(t *testing.T) {
+	const pddl = `(define (domain d) (:requirements :time) (:predicates (on)) (:functions (temp) (rate))
+		(:process warming :parameters () :precondition (on)
+			:effect (and (increase (temp) (* (rate) #t)) (decrease (rate) #t)))
+		(:action heat :parameters () :effect (on))
+		(:event overheat :parameters () :precondition (on) :effect (not (on))))`
+	ast, err := Parse("", strings.NewReader(pddl))
+	if err != nil {
+		t.Fatalf("%s\nparse error: %s", pddl, err)
+	}
+	var b bytes.Buffer
+	PrintDomain(&b, ast.(*Domain))
+	printed := b.String()
+	for _, s := range []string{"(:action heat", "(:process warming", "(:event overheat",
+		"(increase (temp) (* #t (rate)))", "(decrease (rate) (* #t 1))"} {
+		if !strings.Contains(printed, s) {
+			t.Errorf("%s\nexpected the printed domain to contain %s", printed, s)
+		}
+	}
+	ast, err = Parse("", strings.NewReader(printed))
+	if err != nil {
+		t.Fatalf("%s\nparse error: %s", printed, err)
+	}
+	b.Reset()
+	PrintDomain(&b, ast.(*Domain))
+	if b.String() != printed {
+		t.Errorf("expected a reprinted domain to be\n%s\ngot\n%s", printed, b.String())
+	}
+}
+
+func TestPrintProbabilistic(t *testing.T) {
+	const pddl = `(define (domain d) (:requirements :probabilistic-effects) (:predicates (p) (q))
+		(:action a :parameters () :effect (probabilistic 0.25 (p) 0.75 (and (q) (not (p))))))`
+	ast, err := Parse("", strings.NewReader(pddl))
+	if err != nil {
+		t.Fatalf("%s\nparse error: %s", pddl, err)
+	}
+	n, ok := ast.(*Domain).Actions[0].Effect.(*ProbabilisticNode)
+	if !ok || len(n.Formula) != 2 || strings.Join(n.Probabilities, " ") != "0.25 0.75" {
+		t.Fatalf("%s\nexpected a probabilistic effect with two outcomes", pddl)
+	}
+	var b bytes.Buffer
+	PrintDomain(&b, ast.(*Domain))
+	printed := b.String()
+	ast, err = Parse("", strings.NewReader(printed))
+	if err != nil {
+		t.Fatalf("%s\nparse error: %s", printed, err)
+	}
+	b.Reset()
+	PrintDomain(&b, ast.(*Domain))
+	if b.String() != printed {
+		t.Errorf("expected a reprinted domain to be\n%s\ngot\n%s", printed, b.String())
+	}
+}
+
+var objectFluentPrintTests = []struct {
+	pddl string
+
+	// want are strings expected in the printed PDDL.
+	want []string
+}{
+	{`(define (domain d) (:requirements :typing :object-fluents :equality) (:types t)
+		(:predicates (p ?x - t)) (:functions (loc ?x - t) - t)
+		(:action a :parameters (?x ?y - t)
+			:precondition (and (p (loc ?x)) (p (loc (loc ?x))) (= (loc ?x) ?y))
+			:effect (and (assign (loc ?x) ?y) (assign (loc ?y) (loc ?x)))))`,
+		[]string{"(loc ?x - t) - t", "(p (loc ?x))", "(p (loc (loc ?x)))", "(= (loc ?x) ?y)",
+			"(assign (loc ?x) ?y)", "(assign (loc ?y) (loc ?x))"}},
+	{`(define (problem x) (:domain d) (:objects a b - t) (:init (= (loc a) b) (= (loc b) a)) (:goal (p (loc a))))`,
+		[]string{"(= (loc a) b)", "(= (loc b) a)", "(p (loc a))"}},
+}
+
+func TestPrintObjectFluents(t *testing.T) {
+	for _, test := range objectFluentPrintTests {
+		printed := printRoundTrip(t, test.pddl)
+		for _, s := range test.want {
+			if !strings.Contains(printed, s) {
+				t.Errorf("%s\nexpected the printed PDDL to contain %s", printed, s)
+			}
+		}
+	}
+}
+
+func TestParseObjectFluents(t *testing.T) {
+	const pddl = `(define (problem x) (:domain d) (:init (= (loc a) b)) (:goal (p (loc (loc a)))))`
+	ast, err := Parse("", strings.NewReader(pddl))
+	if err != nil {
+		t.Fatalf("%s\nparse error: %s", pddl, err)
+	}
+	p := ast.(*Problem)
+	a := p.Init[0].(*AssignNode)
+	if !a.IsTerm || a.IsNumber || a.Term.Str != "b" || a.Term.Variable {
+		t.Errorf("%s\nexpected the constant b to be assigned, got %+v", pddl, a)
+	}
+	lit := p.Goal.(*LiteralNode)
+	if len(lit.Arguments) != 1 || lit.Arguments[0].Fhead == nil {
+		t.Fatalf("%s\nexpected a function term argument, got %+v", pddl, lit.Arguments)
+	}
+	h := lit.Arguments[0].Fhead
+	if h.Str != "loc" || len(h.Arguments) != 1 || h.Arguments[0].Fhead == nil ||
+		h.Arguments[0].Fhead.Arguments[0].Str != "a" {
+		t.Errorf("%s\nexpected (loc (loc a)), got %s", pddl, lit.Arguments[0])
+	}
+}
+
+// PrintRoundTrip parses and prints a domain or problem, and checks
+// that parsing and printing the printed PDDL gives the same PDDL.
+// It returns the printed PDDL.
+func TestPrintCheckedUntyped(t *testing.T) {
+	const pddl = `(define (domain d) (:predicates (p ?x)) (:action a :parameters (?x) :effect (p ?x)))`
+	ast, err := Parse("", strings.NewReader(pddl))
+	if err != nil {
+		t.Fatalf("%s\nparse error: %s", pddl, err)
+	}
+	d := ast.(*Domain)
+	if errs := Check(d, nil); len(errs) > 0 {
+		t.Fatalf("%s\ncheck error: %s", pddl, errs[0])
+	}
+	var b bytes.Buffer
+	PrintDomain(&b, d)
+	if strings.Contains(b.String(), ":types") {
+		t.Errorf("%s\nexpected no :types section, got\n%s", pddl, b.String())
+	}
+}
+
+func printRoundTrip(t *testing.T, pddl string) string {
+	format := func(pddl string) string {
+		ast, err := Parse("", strings.NewReader(pddl))
+		if err != nil {
+			t.Fatalf("%s\nparse error: %s", pddl, err)
+		}
+		var b bytes.Buffer
+		switch r := ast.(type) {
+		case *Domain:
+			PrintDomain(&b, r)
+		case *Problem:
+			PrintProblem(&b, r)
+		}
+		return b.String()
+	}
+	printed := format(pddl)
+	if reprinted := format(printed); reprinted != printed {
+		t.Errorf("expected the reprinted PDDL to be\n%s\ngot\n%s", printed, reprinted)
+	}
+	return printed
+}
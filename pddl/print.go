@@ -16,7 +16,13 @@ func PrintDomain(w io.Writer, d *Domain) {
 	printPredsDef(w, d.Predicates)
 	printFuncsDef(w, d.Functions)
 	for _, act := range d.Actions {
-		printAction(w, act)
+		printAction(w, ":action", act)
+	}
+	for _, proc := range d.Processes {
+		printAction(w, ":process", proc)
+	}
+	for _, ev := range d.Events {
+		printAction(w, ":event", ev)
 	}
 	fmt.Fprintln(w, ")")
 }
@@ -36,10 +42,6 @@ func printReqsDef(w io.Writer, reqs []Name) {
 }
 
 func printTypesDef(w io.Writer, ts []Type) {
-	if len(ts) == 0 {
-		return
-	}
-	fmt.Fprintf(w, "%s(:types", indent(1))
 	var ids []TypedEntry
 	for _, t := range ts {
 		if t.Location.Line == 0 {
@@ -48,6 +50,10 @@ func printTypesDef(w io.Writer, ts []Type) {
 		}
 		ids = append(ids, t.TypedEntry)
 	}
+	if len(ids) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%s(:types", indent(1))
 	printTypedNames(w, "\n"+indent(2), ids)
 	fmt.Fprintln(w, ")")
 }
@@ -69,10 +75,6 @@ func printPredsDef(w io.Writer, ps []Predicate) {
 	}
 	fmt.Fprintf(w, "%s(:predicates\n", indent(1))
 	for i, p := range ps {
-		if p.Location.Line == 0 {
-			// Skip undefined implicit predicates like =.
-			continue
-		}
 		fmt.Fprintf(w, "%s(%s", indent(2), p.Str)
 		printTypedNames(w, " ", p.Parameters)
 		fmt.Fprint(w, ")")
@@ -102,8 +104,10 @@ func printFuncsDef(w io.Writer, fs []Function) {
 	fmt.Fprintln(w, ")")
 }
 
-func printAction(w io.Writer, act Action) {
-	fmt.Fprintf(w, "%s(:action %s\n", indent(1), act.Name)
+// PrintAction prints an action, or a process or an
+// event, given the keyword of the definition.
+func printAction(w io.Writer, kind string, act Action) {
+	fmt.Fprintf(w, "%s(%s %s\n", indent(1), kind, act.Name)
 	fmt.Fprintf(w, "%s:parameters (", indent(2))
 	printTypedNames(w, "", act.Parameters)
 	fmt.Fprint(w, ")")
@@ -221,7 +225,7 @@ func (lit *LiteralNode) print(w io.Writer, prefix string) {
 	fmt.Fprintf(w, "%s(", prefix)
 	fmt.Fprint(w, lit.Predicate)
 	for _, t := range lit.Arguments {
-		fmt.Fprintf(w, " %s", t.Name)
+		fmt.Fprintf(w, " %s", t)
 	}
 	fmt.Fprint(w, ")")
 	if lit.Negative {
@@ -229,6 +233,17 @@ func (lit *LiteralNode) print(w io.Writer, prefix string) {
 	}
 }
 
+func (n *EqualNode) print(w io.Writer, prefix string) {
+	if n.Negative {
+		fmt.Fprintf(w, "%s(not ", prefix)
+		prefix = ""
+	}
+	fmt.Fprintf(w, "%s(= %s %s)", prefix, n.Left, n.Right)
+	if n.Negative {
+		fmt.Fprint(w, ")")
+	}
+}
+
 func (n *AndNode) print(w io.Writer, prefix string) {
 	fmt.Fprintf(w, "%s(and", prefix)
 	for _, f := range n.Formula {
@@ -285,12 +300,36 @@ func (n *WhenNode) print(w io.Writer, prefix string) {
 	fmt.Fprint(w, ")")
 }
 
+func (n *ProbabilisticNode) print(w io.Writer, prefix string) {
+	fmt.Fprintf(w, "%s(probabilistic", prefix)
+	for i, f := range n.Formula {
+		fmt.Fprintf(w, "\n%s%s\n", prefix+indent(1), n.Probabilities[i])
+		f.print(w, prefix+indent(2))
+	}
+	fmt.Fprint(w, ")")
+}
+
+func (n *TimedNode) print(w io.Writer, prefix string) {
+	fmt.Fprintf(w, "%s(at %s ", prefix, n.Time)
+	n.Formula.print(w, "")
+	fmt.Fprint(w, ")")
+}
+
 func (n *AssignNode) print(w io.Writer, prefix string) {
 	fmt.Fprintf(w, "%s(%s ", prefix, n.Op)
 	n.Lval.print(w)
-	if n.IsNumber {
+	switch {
+	case n.Continuous && n.IsNumber:
+		fmt.Fprintf(w, " (* #t %s)", n.Number)
+	case n.Continuous:
+		fmt.Fprint(w, " (* #t ")
+		n.Fhead.print(w)
+		fmt.Fprint(w, ")")
+	case n.IsNumber:
 		fmt.Fprintf(w, " %s", n.Number)
-	} else {
+	case n.IsTerm:
+		fmt.Fprintf(w, " %s", n.Term)
+	default:
 		fmt.Fprint(w, " ")
 		n.Fhead.print(w)
 	}
@@ -304,7 +343,7 @@ func (h *Fhead) print(w io.Writer) {
 	}
 	fmt.Fprintf(w, "(%s", h.Name)
 	for _, t := range h.Arguments {
-		fmt.Fprintf(w, " %s", t.Name)
+		fmt.Fprintf(w, " %s", t)
 	}
 	fmt.Fprint(w, ")")
 }
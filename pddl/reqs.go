@@ -0,0 +1,269 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+var (
+	// reqOrder is the order in which requirements
+	// are returned by UsedRequirements.
+	reqOrder = []string{
+		":strips",
+		":adl",
+		":typing",
+		":negative-preconditions",
+		":disjunctive-preconditions",
+		":equality",
+		":quantified-preconditions",
+		":existential-preconditions",
+		":universal-preconditions",
+		":conditional-effects",
+		":action-costs",
+		":object-fluents",
+		":timed-initial-literals",
+		":time",
+		":probabilistic-effects",
+	}
+
+	// timeOps are the assignment operators that require :time.
+	timeOps = map[string]bool{
+		"decrease":   true,
+		"scale-up":   true,
+		"scale-down": true,
+	}
+
+	// adlReqs are the requirements implied by :adl.
+	adlReqs = []string{
+		":typing",
+		":negative-preconditions",
+		":disjunctive-preconditions",
+		":equality",
+		":quantified-preconditions",
+		":conditional-effects",
+	}
+)
+
+// UsedRequirements returns the minimal list of requirements needed by
+// the domain and problem.  The problem may be nil, in which case only
+// the requirements of the domain are returned.
+//
+// The list always contains :strips, unless it contains :adl.
+// :existential-preconditions and :universal-preconditions are
+// replaced by :quantified-preconditions when both are needed, and
+// the requirements implied by :adl are replaced by :adl when all
+// of them are needed.
+//
+// The domain and problem must have been checked, but they
+// may have MissingRequirementErrors.
+func UsedRequirements(d *Domain, p *Problem) []string {
+	used := neededReqs(d, p)
+	used[":strips"] = true
+	return minimalReqs(used)
+}
+
+// FixRequirements sets the :requirements of the domain and problem
+// to the minimal requirements that they need.  The problem's
+// requirements are only those that are not already needed by the
+// domain.  The problem may be nil.
+//
+// The domain and problem must have been checked, but they
+// may have MissingRequirementErrors.
+func FixRequirements(d *Domain, p *Problem) {
+	dom := neededReqs(d, nil)
+	dom[":strips"] = true
+	d.Requirements = reqNames(minimalReqs(dom), d.Location)
+	if p == nil {
+		return
+	}
+	prob := make(map[string]bool)
+	for r := range neededReqs(d, p) {
+		if !dom[r] {
+			prob[r] = true
+		}
+	}
+	p.Requirements = reqNames(minimalReqs(prob), p.Location)
+}
+
+// MinimalReqs returns a minimal, ordered list of requirements
+// that implies each requirement in the set.  The set is not modified.
+func minimalReqs(set map[string]bool) []string {
+	used := make(map[string]bool, len(set))
+	for r := range set {
+		used[r] = true
+	}
+	if used[":existential-preconditions"] && used[":universal-preconditions"] {
+		delete(used, ":existential-preconditions")
+		delete(used, ":universal-preconditions")
+		used[":quantified-preconditions"] = true
+	}
+	adl := true
+	for _, r := range adlReqs {
+		adl = adl && used[r]
+	}
+	if adl {
+		for _, r := range adlReqs {
+			delete(used, r)
+		}
+		delete(used, ":strips")
+		used[":adl"] = true
+	}
+	var reqs []string
+	for _, r := range reqOrder {
+		if used[r] {
+			reqs = append(reqs, r)
+		}
+	}
+	return reqs
+}
+
+// ReqNames returns a slice of Names for the requirements,
+// each with the given location.
+func reqNames(reqs []string, loc Location) []Name {
+	names := make([]Name, len(reqs))
+	for i, r := range reqs {
+		names[i] = Name{Str: r, Location: loc}
+	}
+	return names
+}
+
+// UsedReqs returns the set of requirements that are needed by the domain
+// and problem.  :strips is always considered to be needed, and :adl and
+// :quantified-preconditions are needed if any of the requirements that
+// they imply are needed.
+func usedReqs(d *Domain, p *Problem) map[string]bool {
+	used := neededReqs(d, p)
+	used[":strips"] = true
+	if used[":universal-preconditions"] || used[":existential-preconditions"] {
+		used[":quantified-preconditions"] = true
+	}
+	for _, r := range adlReqs {
+		if used[r] {
+			used[":adl"] = true
+		}
+	}
+	return used
+}
+
+// NeededReqs returns the set of requirements that are directly
+// needed by the domain and problem, not including :strips,
+// :adl, or :quantified-preconditions.
+func neededReqs(d *Domain, p *Problem) map[string]bool {
+	used := make(map[string]bool)
+	for _, t := range d.Types {
+		if t.Location.Line != 0 {
+			used[":typing"] = true
+		}
+	}
+	typedList := func(ents []TypedEntry) {
+		for _, e := range ents {
+			for _, t := range e.Types {
+				if t.Location.Line != 0 {
+					used[":typing"] = true
+				}
+			}
+		}
+	}
+	typedList(d.Constants)
+	for _, pred := range d.Predicates {
+		typedList(pred.Parameters)
+	}
+	for i, f := range d.Functions {
+		if numeric(&d.Functions[i]) {
+			used[":action-costs"] = true
+		} else {
+			used[":object-fluents"] = true
+		}
+		typedList(f.Parameters)
+	}
+	formula := func(f Formula) {
+		walkFormula(f, func(f Formula) {
+			switch n := f.(type) {
+			case *EqualNode:
+				used[":equality"] = true
+			case *NotNode:
+				if isAtom(n.Formula) {
+					used[":negative-preconditions"] = true
+				} else {
+					used[":disjunctive-preconditions"] = true
+				}
+			case *OrNode, *ImplyNode:
+				used[":disjunctive-preconditions"] = true
+			case *ForallNode:
+				typedList(n.Variables)
+				if n.IsEffect {
+					used[":conditional-effects"] = true
+				} else {
+					used[":universal-preconditions"] = true
+				}
+			case *ExistsNode:
+				typedList(n.Variables)
+				used[":existential-preconditions"] = true
+			case *WhenNode:
+				used[":conditional-effects"] = true
+			case *TimedNode:
+				used[":timed-initial-literals"] = true
+			case *ProbabilisticNode:
+				used[":probabilistic-effects"] = true
+			case *AssignNode:
+				switch {
+				case n.Lval.Definition != nil && !numeric(n.Lval.Definition):
+					used[":object-fluents"] = true
+				case n.Continuous || timeOps[n.Op.Key()]:
+					used[":time"] = true
+				default:
+					used[":action-costs"] = true
+				}
+			}
+			if hasFunctionTerm(f) {
+				used[":object-fluents"] = true
+			}
+		})
+	}
+	if len(d.Processes) > 0 || len(d.Events) > 0 {
+		used[":time"] = true
+	}
+	for _, act := range d.Happenings() {
+		typedList(act.Parameters)
+		if act.Precondition != nil {
+			formula(act.Precondition)
+		}
+		if act.Effect != nil {
+			formula(act.Effect)
+		}
+	}
+	if p != nil {
+		typedList(p.Objects)
+		for _, f := range p.Init {
+			formula(f)
+		}
+		formula(p.Goal)
+	}
+	if used[":time"] && (p == nil || p.Metric != MetricMinCost) {
+		// :time allows numeric functions and
+		// assignments without :action-costs.
+		delete(used, ":action-costs")
+	}
+	return used
+}
+
+// HasFunctionTerm returns true if a formula has a
+// function term as an argument of a literal, an
+// equality, or a function.
+func hasFunctionTerm(f Formula) bool {
+	var terms []Term
+	switch n := f.(type) {
+	case *LiteralNode:
+		terms = n.Arguments
+	case *EqualNode:
+		terms = []Term{n.Left, n.Right}
+	case *AssignNode:
+		terms = n.Lval.Arguments
+		if !n.IsNumber && !n.IsTerm {
+			terms = append(terms[:len(terms):len(terms)], n.Fhead.Arguments...)
+		}
+	}
+	for _, t := range terms {
+		if t.Fhead != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var usedReqsTests = []struct {
+	domain, problem string
+	reqs            []string
+}{
+	{`(define (domain d) (:predicates (p))
+		(:action a :parameters () :effect (p)))`, "",
+		[]string{":strips"}},
+	{`(define (domain d) (:requirements :adl) (:predicates (p))
+		(:action a :parameters () :effect (p)))`, "",
+		[]string{":strips"}},
+	{`(define (domain d) (:types t) (:predicates (p ?x - t))
+		(:action a :parameters (?x - t) :precondition (not (p ?x)) :effect (p ?x)))`, "",
+		[]string{":strips", ":typing", ":negative-preconditions"}},
+	{`(define (domain d) (:predicates (p ?x) (q))
+		(:action a :parameters () :precondition (exists (?x) (p ?x)) :effect (q)))`, "",
+		[]string{":strips", ":existential-preconditions"}},
+	{`(define (domain d) (:predicates (p ?x) (q))
+		(:action a :parameters () :precondition (and (exists (?x) (p ?x)) (forall (?x) (p ?x)))
+			:effect (q)))`, "",
+		[]string{":strips", ":quantified-preconditions"}},
+	{`(define (domain d) (:types t) (:predicates (p ?x - t) (q))
+		(:action a :parameters (?x - t)
+			:precondition (and (or (q) (not (p ?x))) (= ?x ?x)
+				(exists (?y - t) (p ?y)) (forall (?y - t) (p ?y)))
+			:effect (when (q) (p ?x))))`, "",
+		[]string{":adl"}},
+	{`(define (domain d) (:predicates (p)) (:functions (total-cost))
+		(:action a :parameters () :effect (and (p) (increase (total-cost) 1))))`, "",
+		[]string{":strips", ":action-costs"}},
+	{`(define (domain d) (:types t) (:predicates (p ?x - t)) (:functions (loc ?x - t) - t)
+		(:action a :parameters (?x - t) :precondition (p (loc ?x)) :effect (assign (loc ?x) ?x)))`, "",
+		[]string{":strips", ":typing", ":object-fluents"}},
+	{`(define (domain d) (:predicates (p) (q))
+		(:action a :parameters () :effect (probabilistic 0.5 (p) 0.5 (q))))`, "",
+		[]string{":strips", ":probabilistic-effects"}},
+	{`(define (domain d) (:requirements :time) (:predicates (on)) (:functions (temp))
+		(:action a :parameters () :effect (and (on) (assign (temp) 0)))
+		(:process p :parameters () :precondition (on) :effect (increase (temp) #t)))`, "",
+		[]string{":strips", ":time"}},
+	{`(define (domain d) (:predicates (p))
+		(:action a :parameters () :effect (p)))`,
+		`(define (problem x) (:domain d) (:init (at 5 (p))) (:goal (p)))`,
+		[]string{":strips", ":timed-initial-literals"}},
+	{`(define (domain d) (:predicates (p))
+		(:action a :parameters () :effect (p)))`,
+		`(define (problem x) (:domain d) (:init) (:goal (not (p))))`,
+		[]string{":strips", ":negative-preconditions"}},
+}
+
+func TestUsedRequirements(t *testing.T) {
+	for _, test := range usedReqsTests {
+		d, p := mustCheck(t, test.domain, test.problem, CodeMissingRequirement)
+		reqs := UsedRequirements(d, p)
+		if strings.Join(reqs, " ") != strings.Join(test.reqs, " ") {
+			t.Errorf("%s\n%s\nexpected %v, got %v", test.domain, test.problem, test.reqs, reqs)
+		}
+	}
+}
+
+func TestFixRequirements(t *testing.T) {
+	d, p := mustCheck(t, `(define (domain d) (:requirements :adl) (:types t)
+		(:predicates (p ?x - t))
+		(:action a :parameters (?x - t) :precondition (p ?x) :effect (not (p ?x))))`,
+		`(define (problem x) (:domain d) (:objects o - t)
+		(:init (p o)) (:goal (forall (?x - t) (not (p ?x)))))`, CodeMissingRequirement)
+	FixRequirements(d, p)
+
+	var db, pb bytes.Buffer
+	PrintDomain(&db, d)
+	PrintProblem(&pb, p)
+	d, p = mustCheck(t, db.String(), pb.String())
+	if s := namesString(d.Requirements); s != ":strips :typing" {
+		t.Errorf("%s\nexpected domain requirements :strips :typing, got %s", db.String(), s)
+	}
+	if s := namesString(p.Requirements); s != ":negative-preconditions :universal-preconditions" {
+		t.Errorf("%s\nexpected problem requirements :negative-preconditions :universal-preconditions, got %s",
+			pb.String(), s)
+	}
+}
+
+func TestFixRequirementsImplied(t *testing.T) {
+	tests := []struct {
+		domain, problem, reqs string
+	}{
+		{`(define (domain d) (:requirements :adl) (:types t) (:predicates (p ?x - t) (q))
+			(:action a :parameters (?x - t)
+				:precondition (and (or (q) (not (p ?x))) (= ?x ?x)
+					(exists (?y - t) (p ?y)) (forall (?y - t) (p ?y)))
+				:effect (when (q) (p ?x))))`,
+			`(define (problem x) (:domain d) (:init) (:goal (forall (?y - t) (not (p ?y)))))`,
+			":adl"},
+		{`(define (domain d) (:requirements :quantified-preconditions) (:predicates (p ?x) (q))
+			(:action a :parameters ()
+				:precondition (and (exists (?y) (p ?y)) (forall (?y) (p ?y)))
+				:effect (q)))`,
+			`(define (problem x) (:domain d) (:init) (:goal (forall (?y) (p ?y))))`,
+			":strips :quantified-preconditions"},
+	}
+	for _, test := range tests {
+		d, p := mustCheck(t, test.domain, test.problem, CodeMissingRequirement)
+		FixRequirements(d, p)
+		if s := namesString(d.Requirements); s != test.reqs {
+			t.Errorf("%s\nexpected domain requirements %s, got %s", test.domain, test.reqs, s)
+		}
+		if len(p.Requirements) > 0 {
+			t.Errorf("%s\nexpected no problem requirements, got %s", test.domain, namesString(p.Requirements))
+		}
+	}
+}
+
+func TestFixRequirementsTime(t *testing.T) {
+	for _, op := range []string{"decrease", "scale-up", "scale-down"} {
+		d, _ := mustCheck(t, `(define (domain d) (:requirements :time) (:functions (f))
+			(:action a :parameters () :effect (`+op+` (f) 1)))`, "", CodeMissingRequirement)
+		FixRequirements(d, nil)
+
+		var db bytes.Buffer
+		PrintDomain(&db, d)
+		d, _ = mustCheck(t, db.String(), "")
+		if s := namesString(d.Requirements); s != ":strips :time" {
+			t.Errorf("%s\nexpected domain requirements :strips :time, got %s", db.String(), s)
+		}
+	}
+}
+
+func namesString(ns []Name) string {
+	strs := make([]string, len(ns))
+	for i, n := range ns {
+		strs[i] = n.Str
+	}
+	return strings.Join(strs, " ")
+}
@@ -0,0 +1,284 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// A State is a set of ground atoms.  Each atom is
+// represented by the string returned by Atom.
+type State map[string]bool
+
+// Atom returns the representation of a ground atom in a State:
+// the keys of the predicate and its arguments separated by spaces.
+func Atom(pred string, args ...string) string {
+	strs := make([]string, len(args)+1)
+	strs[0] = fold(pred)
+	for i, a := range args {
+		strs[i+1] = fold(a)
+	}
+	return strings.Join(strs, " ")
+}
+
+// InitialState returns the state of the positive literals in the :init
+// section of a problem.  Timed initial literals and function
+// assignments are not included.
+func InitialState(p *Problem) State {
+	s := make(State)
+	for _, f := range p.Init {
+		lit, ok := f.(*LiteralNode)
+		if !ok || lit.Negative {
+			continue
+		}
+		args := make([]string, len(lit.Arguments))
+		for i, a := range lit.Arguments {
+			args[i] = a.Key()
+		}
+		s[Atom(lit.Predicate.Key(), args...)] = true
+	}
+	return s
+}
+
+// Apply returns the state that results from applying the action to the
+// given state with its parameters bound to the named objects.  The given
+// state is not modified.  The outcome of each probabilistic effect is
+// sampled using rng.  An error is returned if an argument is not an object
+// of its parameter's type or if the precondition does not hold.
+//
+// As in PDDL, all conditions are evaluated in the given state, and if an
+// atom is both added and deleted then it is added.  Assignments to functions
+// are ignored, and conditions or effects with object fluent terms are
+// not supported.
+//
+// The action must have been checked with its problem so that each type
+// is linked to the objects of the type.
+func (a *Action) Apply(s State, args []string, rng *rand.Rand) (State, error) {
+	if len(args) != len(a.Parameters) {
+		return nil, fmt.Errorf("%s: %s requires %d arguments, got %d", a.Loc(), a.Name, len(a.Parameters), len(args))
+	}
+	sim := &sim{
+		state: s,
+		rng:   rng,
+		bind:  make(map[*TypedEntry]*TypedEntry, len(args)),
+	}
+	for i, arg := range args {
+		p := &a.Parameters[i]
+		if sim.bind[p] = findObject(p, fold(arg)); sim.bind[p] == nil {
+			return nil, fmt.Errorf("%s: %s is not an object of the type of parameter %s", a.Loc(), arg, p.Name)
+		}
+	}
+	if a.Precondition != nil {
+		switch ok, err := sim.holds(a.Precondition); {
+		case err != nil:
+			return nil, err
+		case !ok:
+			return nil, fmt.Errorf("%s: precondition of %s does not hold", a.Loc(), a.Name)
+		}
+	}
+	if a.Effect != nil {
+		if err := sim.effect(a.Effect); err != nil {
+			return nil, err
+		}
+	}
+	next := make(State, len(s)+len(sim.add))
+	for atom := range s {
+		next[atom] = true
+	}
+	for _, atom := range sim.del {
+		delete(next, atom)
+	}
+	for _, atom := range sim.add {
+		next[atom] = true
+	}
+	return next, nil
+}
+
+// A sim holds the variable bindings and the atoms
+// added and deleted while applying an action.
+type sim struct {
+	state State
+	rng   *rand.Rand
+
+	// Bind maps the definition of each bound variable to
+	// the definition of the object to which it is bound.
+	bind map[*TypedEntry]*TypedEntry
+
+	add, del []string
+}
+
+// Holds returns true if the condition holds in the state.
+func (s *sim) holds(f Formula) (bool, error) {
+	switch n := f.(type) {
+	case *AndNode:
+		for _, g := range n.Formula {
+			if ok, err := s.holds(g); !ok || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	case *OrNode:
+		for _, g := range n.Formula {
+			if ok, err := s.holds(g); ok || err != nil {
+				return ok, err
+			}
+		}
+		return false, nil
+	case *NotNode:
+		ok, err := s.holds(n.Formula)
+		return !ok, err
+	case *ImplyNode:
+		if ok, err := s.holds(n.Left); !ok || err != nil {
+			return err == nil, err
+		}
+		return s.holds(n.Right)
+	case *ForallNode:
+		return s.each(n.Variables, func() (bool, error) { return s.holds(n.Formula) })
+	case *ExistsNode:
+		none, err := s.each(n.Variables, func() (bool, error) {
+			ok, err := s.holds(n.Formula)
+			return !ok, err
+		})
+		return !none, err
+	case *LiteralNode:
+		atom, err := s.atom(n)
+		return s.state[atom] != n.Negative, err
+	case *EqualNode:
+		l, err := s.object(n.Left)
+		if err != nil {
+			return false, err
+		}
+		r, err := s.object(n.Right)
+		return (l == r) != n.Negative, err
+	}
+	return false, fmt.Errorf("%s: %T is not supported in a condition", f.(Locer).Loc(), f)
+}
+
+// Effect adds the atoms added and deleted by the effect to s.add and s.del.
+func (s *sim) effect(f Formula) error {
+	switch n := f.(type) {
+	case *AndNode:
+		for _, g := range n.Formula {
+			if err := s.effect(g); err != nil {
+				return err
+			}
+		}
+	case *ForallNode:
+		_, err := s.each(n.Variables, func() (bool, error) { return true, s.effect(n.Formula) })
+		return err
+	case *WhenNode:
+		if ok, err := s.holds(n.Condition); !ok || err != nil {
+			return err
+		}
+		return s.effect(n.Formula)
+	case *ProbabilisticNode:
+		r := s.rng.Float64()
+		sum := 0.0
+		for i, p := range n.Probabilities {
+			pr, err := strconv.ParseFloat(p, 64)
+			if err != nil {
+				return fmt.Errorf("%s: malformed probability %s", n.Loc(), p)
+			}
+			if sum += pr; r < sum {
+				return s.effect(n.Formula[i])
+			}
+		}
+	case *LiteralNode:
+		atom, err := s.atom(n)
+		if err != nil {
+			return err
+		}
+		if n.Negative {
+			s.del = append(s.del, atom)
+		} else {
+			s.add = append(s.add, atom)
+		}
+	case *AssignNode:
+		// Functions are not part of the state.
+	default:
+		return fmt.Errorf("%s: %T is not supported in an effect", f.(Locer).Loc(), f)
+	}
+	return nil
+}
+
+// Each binds the variables to each combination of objects of their
+// types, calling fn for each binding until fn returns false.  Each
+// returns false if fn returned false.
+func (s *sim) each(vs []TypedEntry, fn func() (bool, error)) (bool, error) {
+	if len(vs) == 0 {
+		return fn()
+	}
+	v := &vs[0]
+	defer delete(s.bind, v)
+	for _, obj := range objects(v) {
+		s.bind[v] = obj
+		if ok, err := s.each(vs[1:], fn); !ok || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Atom returns the ground atom of the literal under the current bindings.
+func (s *sim) atom(lit *LiteralNode) (string, error) {
+	args := make([]string, len(lit.Arguments))
+	for i, t := range lit.Arguments {
+		obj, err := s.object(t)
+		if err != nil {
+			return "", err
+		}
+		args[i] = obj.Key()
+	}
+	return Atom(lit.Predicate.Key(), args...), nil
+}
+
+// Object returns the definition of the object to which
+// the term refers under the current bindings.
+func (s *sim) object(t Term) (*TypedEntry, error) {
+	switch {
+	case t.Fhead != nil:
+		return nil, fmt.Errorf("%s: object fluent %s is not supported", t.Loc(), t)
+	case !t.Variable:
+		return t.Definition, nil
+	}
+	obj := s.bind[t.Definition]
+	if obj == nil {
+		return nil, fmt.Errorf("%s: unbound variable %s", t.Loc(), t)
+	}
+	return obj, nil
+}
+
+// Objects returns the objects of the entry's types, each only once.
+func objects(ent *TypedEntry) []*TypedEntry {
+	if len(ent.Types) == 1 && ent.Types[0].Definition != nil {
+		return ent.Types[0].Definition.Domain
+	}
+	var objs []*TypedEntry
+	seen := make(map[*TypedEntry]bool)
+	for _, t := range ent.Types {
+		if t.Definition == nil {
+			continue
+		}
+		for _, obj := range t.Definition.Domain {
+			if !seen[obj] {
+				seen[obj] = true
+				objs = append(objs, obj)
+			}
+		}
+	}
+	return objs
+}
+
+// FindObject returns the object of the entry's types with the given key,
+// or nil if there is no such object.
+func findObject(ent *TypedEntry, key string) *TypedEntry {
+	for _, obj := range objects(ent) {
+		if obj.Key() == key {
+			return obj
+		}
+	}
+	return nil
+}
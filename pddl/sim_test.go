@@ -0,0 +1,129 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const simDomain = `(define (domain d)
+	(:requirements :adl :probabilistic-effects)
+	(:types loc truck)
+	(:constants depot - loc)
+	(:predicates (at ?t - truck ?l - loc) (road ?a ?b - loc) (clean ?l - loc) (p) (q))
+	(:action drive
+		:parameters (?t - truck ?a ?b - loc)
+		:precondition (and (at ?t ?a) (or (road ?a ?b) (road ?b ?a)) (not (= ?a ?b)))
+		:effect (and (not (at ?t ?a)) (at ?t ?b)))
+	(:action sweep
+		:parameters ()
+		:effect (forall (?l - loc) (when (exists (?t - truck) (at ?t ?l)) (clean ?l))))
+	(:action flip
+		:parameters ()
+		:effect (and (when (p) (not (p))) (when (not (p)) (p))))
+	(:action touch
+		:parameters ()
+		:effect (and (not (p)) (p)))
+	(:action home
+		:parameters (?t - truck)
+		:precondition (imply (p) (at ?t depot))
+		:effect (at ?t depot)))`
+
+const simProblem = `(define (problem x) (:domain d)
+	(:objects a b c - loc t1 t2 - truck)
+	(:init (at t1 a) (at t2 depot) (road a b) (road c b) (p))
+	(:goal (and)))`
+
+var simTests = []struct {
+	action string
+	args   []string
+
+	// state is the expected state after the action,
+	// or nil if an error is expected.
+	state []string
+}{
+	{"drive", []string{"t1", "a", "b"},
+		[]string{"at t1 b", "at t2 depot", "p", "road a b", "road c b"}},
+	{"drive", []string{"T1", "A", "B"},
+		[]string{"at t1 b", "at t2 depot", "p", "road a b", "road c b"}},
+	{"drive", []string{"t1", "a", "c"}, nil},
+	{"drive", []string{"t1", "a", "a"}, nil},
+	{"drive", []string{"a", "a", "b"}, nil},
+	{"drive", []string{"t1", "a", "nowhere"}, nil},
+	{"drive", []string{"t1", "a"}, nil},
+	{"sweep", nil,
+		[]string{"at t1 a", "at t2 depot", "clean a", "clean depot", "p", "road a b", "road c b"}},
+	{"flip", nil,
+		[]string{"at t1 a", "at t2 depot", "road a b", "road c b"}},
+	{"touch", nil,
+		[]string{"at t1 a", "at t2 depot", "p", "road a b", "road c b"}},
+	{"home", []string{"t2"},
+		[]string{"at t1 a", "at t2 depot", "p", "road a b", "road c b"}},
+	{"home", []string{"t1"}, nil},
+}
+
+func TestApply(t *testing.T) {
+	d, p := mustCheck(t, simDomain, simProblem)
+	init := InitialState(p)
+	initAtoms := atoms(init)
+	rng := rand.New(rand.NewSource(1))
+	for _, test := range simTests {
+		act := findAction(test.action, d.Actions)
+		s, err := act.Apply(init, test.args, rng)
+		switch {
+		case test.state == nil && err == nil:
+			t.Errorf("%s %v: expected an error, got %v", test.action, test.args, atoms(s))
+		case test.state != nil && err != nil:
+			t.Errorf("%s %v: unexpected error: %s", test.action, test.args, err)
+		case test.state != nil && !sameStrings(atoms(s), test.state):
+			t.Errorf("%s %v: expected %v, got %v", test.action, test.args, test.state, atoms(s))
+		}
+		if !sameStrings(atoms(init), initAtoms) {
+			t.Fatalf("%s %v: modified the initial state: %v", test.action, test.args, atoms(init))
+		}
+	}
+}
+
+func TestApplyProbabilistic(t *testing.T) {
+	d, p := mustCheck(t, `(define (domain d) (:requirements :probabilistic-effects)
+		(:predicates (p) (q) (r))
+		(:action a :parameters () :effect (and (r) (probabilistic 0.25 (p) 0.5 (q)))))`,
+		`(define (problem x) (:domain d) (:init) (:goal (and)))`)
+	init := InitialState(p)
+	rng := rand.New(rand.NewSource(1))
+
+	const n = 10000
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		s, err := d.Actions[0].Apply(init, nil, rng)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		counts[strings.Join(atoms(s), ",")]++
+	}
+	want := map[string]float64{"p,r": 0.25, "q,r": 0.5, "r": 0.25}
+	for outcome := range counts {
+		if _, ok := want[outcome]; !ok {
+			t.Errorf("unexpected outcome %s", outcome)
+		}
+	}
+	for outcome, pr := range want {
+		if f := float64(counts[outcome]) / n; math.Abs(f-pr) > 0.02 {
+			t.Errorf("expected outcome %s with probability %g, got %g", outcome, pr, f)
+		}
+	}
+}
+
+// Atoms returns the sorted atoms of a state.
+func atoms(s State) []string {
+	var strs []string
+	for atom := range s {
+		strs = append(strs, atom)
+	}
+	sort.Strings(strs)
+	return strs
+}
@@ -0,0 +1,53 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+package pddl
+
+import (
+	"strings"
+)
+
+// Fold returns the canonical, case-folded key of a name.  PDDL is
+// case-insensitive, so names and keywords are compared by their keys.
+// The original spelling is kept in Name.Str for printing.
+func fold(s string) string {
+	return strings.ToLower(s)
+}
+
+// A symtab is a table of symbols interned by the lexer, so that the
+// text and the key of each distinct token are only allocated once.
+type symtab struct {
+	syms map[string]*symbol
+}
+
+// A symbol is an interned string and its canonical key.
+type symbol struct {
+	text, key string
+}
+
+// NewSymtab returns a new, empty symbol table.
+func newSymtab() *symtab {
+	return &symtab{syms: make(map[string]*symbol)}
+}
+
+// Intern returns the symbol for the text.
+func (s *symtab) intern(text []byte) *symbol {
+	if sym, ok := s.syms[string(text)]; ok {
+		return sym
+	}
+	return s.add(string(text))
+}
+
+// Add adds a string that is not yet in the table.
+func (s *symtab) add(str string) *symbol {
+	key := fold(str)
+	if key == str {
+		key = str
+	} else if sym, ok := s.syms[key]; ok {
+		key = sym.text
+	} else {
+		s.syms[key] = &symbol{key, key}
+	}
+	sym := &symbol{str, key}
+	s.syms[str] = sym
+	return sym
+}
@@ -4,24 +4,32 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"planit/pddl"
 	"runtime/pprof"
+	"strings"
 )
 
 var (
 	cpuProfile = flag.String("cpuprof", "", "write CPU profile to this file")
 	memProfile = flag.String("memprof", "", "write memory profile to this file")
 	ignoreReqs = flag.Bool("missing-requirements", false, "allow missing requirement errors")
+	ignore     = flag.String("ignore", "", "comma-separated list of error codes to ignore")
+	fixReqs    = flag.Bool("fix-requirements", false, "ignore missing requirement errors and print the minimal :requirements")
+	lint       = flag.Bool("lint", false, "report warnings if there are no errors")
+	dialect    = flag.String("dialect", "ipc", "the PDDL dialect to parse: strict or ipc")
+	noWarn     = flag.String("nowarn", "", "comma-separated list of warning kinds to suppress")
 )
 
 func main() {
 	flag.Parse()
 	log.SetFlags(0)
-	var dom *pddl.Domain
-	var prob *pddl.Problem
-
+	opts, ok := pddl.Dialects[*dialect]
+	if !ok {
+		log.Fatalf("unknown dialect %s", *dialect)
+	}
 	if len(flag.Args()) == 0 {
 		return
 	}
@@ -44,24 +52,17 @@ func main() {
 		return
 	}
 
-	ast, err := parseFile(flag.Arg(0))
-	if err != nil {
-		log.Fatal(err)
-	}
-	switch r := ast.(type) {
-	case *pddl.Domain:
-		dom = r
-	case *pddl.Problem:
-		prob = r
-	default:
-		panic("Impossible")
-	}
-
-	if len(flag.Args()) > 1 {
-		ast, err := parseFile(flag.Arg(1))
+	var asts []interface{}
+	for _, path := range flag.Args() {
+		as, err := parseFile(path, opts)
 		if err != nil {
 			log.Fatal(err)
 		}
+		asts = append(asts, as...)
+	}
+	var dom *pddl.Domain
+	var probs []*pddl.Problem
+	for _, ast := range asts {
 		switch r := ast.(type) {
 		case *pddl.Domain:
 			if dom != nil {
@@ -69,48 +70,129 @@ func main() {
 			}
 			dom = r
 		case *pddl.Problem:
-			if dom == nil {
-				log.Fatal("no domain specified")
-			}
-			prob = r
+			probs = append(probs, r)
 		default:
 			panic("Impossible")
 		}
 	}
+	if dom == nil {
+		log.Fatal("no domain specified")
+	}
 
-	const maxErrors = 5
-	if errs := pddl.Check(dom, prob); len(errs) > 0 {
-		if *ignoreReqs {
-			var report []error
-			for _, e := range errs {
-				if _, ok := e.(pddl.MissingRequirementError); ok && *ignoreReqs {
-					continue
-				}
-				report = append(report, e)
-			}
-			errs = report
+	// The domain is checked once on its own, so that its
+	// errors are not reported again for each problem.
+	if !report(pddl.Check(dom, nil)) {
+		os.Exit(1)
+	}
+	seen := make(map[pddl.Warning]bool)
+	if len(probs) == 0 {
+		lintDomain(dom, nil, seen)
+	}
+	ok = true
+	for _, prob := range probs {
+		if report(pddl.Check(dom, prob)) {
+			lintDomain(dom, prob, seen)
+		} else {
+			ok = false
+		}
+	}
+	if !ok {
+		os.Exit(1)
+	}
+
+	if *fixReqs {
+		pddl.FixRequirements(dom, nil)
+		fmt.Printf("domain %s: %s\n", dom.Name, reqsString(dom.Requirements))
+		for _, prob := range probs {
+			pddl.FixRequirements(dom, prob)
+			fmt.Printf("problem %s: %s\n", prob.Name, reqsString(prob.Requirements))
 		}
-		for i := 0; i < maxErrors && i < len(errs); i++ {
-			log.Printf(errs[i].Error())
+	}
+}
+
+// Report prints the errors that are not ignored.  It
+// returns false if there are errors that are not ignored.
+func report(errs []error) bool {
+	const maxErrors = 5
+	ignored := codeSet(*ignore)
+	if *ignoreReqs || *fixReqs {
+		ignored[pddl.CodeMissingRequirement] = true
+	}
+	var reported []error
+	for _, e := range errs {
+		if c, ok := e.(pddl.Coder); ok && ignored[c.Code()] {
+			continue
 		}
-		if len(errs) > maxErrors {
-			log.Print("too many errors, truncating list")
+		reported = append(reported, e)
+	}
+	errs = reported
+	for i := 0; i < maxErrors && i < len(errs); i++ {
+		log.Print(errs[i])
+	}
+	if len(errs) > maxErrors {
+		log.Print("too many errors, truncating list")
+	}
+	if len(errs) == 0 {
+		return true
+	}
+	errors := "errors"
+	if len(errs) == 1 {
+		errors = "error"
+	}
+	log.Printf("%d %s\n", len(errs), errors)
+	return false
+}
+
+// LintDomain prints the lint warnings for a domain and an optional
+// problem if -lint is set.  Warnings in seen were already printed for
+// another problem, and they are not printed again.
+func lintDomain(dom *pddl.Domain, prob *pddl.Problem, seen map[pddl.Warning]bool) {
+	if !*lint {
+		return
+	}
+	suppress := codeSet(*noWarn)
+	for _, w := range pddl.Lint(dom, prob) {
+		if !suppress[w.Kind] && !seen[w] {
+			log.Print("warning: ", w)
 		}
-		if len(errs) > 0 {
-			errors := "errors"
-			if len(errs) == 1 {
-				errors = "error"
-			}
-			log.Fatalf("%d %s\n", len(errs), errors)
+		seen[w] = true
+	}
+}
+
+// ReqsString returns the :requirements section for the requirements.
+func reqsString(reqs []pddl.Name) string {
+	s := "(:requirements"
+	for _, r := range reqs {
+		s += " " + r.Str
+	}
+	return s + ")"
+}
+
+// CodeSet returns the set of codes in a comma-separated list.
+func codeSet(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, c := range strings.Split(list, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			set[c] = true
 		}
 	}
+	return set
 }
 
-func parseFile(path string) (interface{}, error) {
-	file, err := os.Open(path)
+// ParseFile parses each define in a file, printing
+// any warnings that are not suppressed.
+func parseFile(path string, opts pddl.ParseOptions) ([]interface{}, error) {
+	file, err := pddl.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
-	return pddl.Parse(path, file)
+	asts, ws, err := pddl.ParseAllWithOptions(path, file, opts)
+	suppress := codeSet(*noWarn)
+	for _, w := range ws {
+		if !suppress[w.Kind] {
+			log.Print("warning: ", w)
+		}
+	}
+	return asts, err
 }
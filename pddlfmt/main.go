@@ -3,32 +3,146 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"os"
 	"planit/pddl"
 )
 
+var (
+	inferTypes = flag.Bool("infer-types", false, "print a typed domain and problem inferred from an untyped domain and problem")
+	dialect    = flag.String("dialect", "ipc", "the PDDL dialect to parse: strict or ipc")
+	fixReqs    = flag.Bool("fix-requirements", false, "print a domain and optional problem with their minimal :requirements")
+
+	// opts are the parse options of the dialect.
+	opts pddl.ParseOptions
+)
+
 func main() {
+	flag.Parse()
 	log.SetFlags(0)
-	for _, path := range os.Args[1:] {
-		file, err := os.Open(path)
+	var ok bool
+	if opts, ok = pddl.Dialects[*dialect]; !ok {
+		log.Fatalf("unknown dialect %s", *dialect)
+	}
+	if *inferTypes {
+		printInferred()
+		return
+	}
+	if *fixReqs {
+		printFixedReqs()
+		return
+	}
+	for _, path := range flag.Args() {
+		file, err := pddl.Open(path)
 		if err != nil {
 			log.Println(err)
 			continue
 		}
-		ast, err := pddl.Parse(path, file)
+		asts, ws, err := pddl.ParseAllWithOptions(path, file, opts)
+		file.Close()
+		printWarnings(ws)
+		for _, ast := range asts {
+			switch r := ast.(type) {
+			case *pddl.Domain:
+				pddl.PrintDomain(os.Stdout, r)
+			case *pddl.Problem:
+				pddl.PrintProblem(os.Stdout, r)
+			default:
+				panic("impossible")
+			}
+		}
 		if err != nil {
 			log.Println(err)
-			continue
 		}
-		switch r := ast.(type) {
-		case *pddl.Domain:
-			pddl.PrintDomain(os.Stdout, r)
-		case *pddl.Problem:
-			pddl.PrintProblem(os.Stdout, r)
-		default:
-			panic("impossible")
+	}
+}
+
+// PrintInferred prints the domain and problem given as
+// arguments after inferring types for them.
+func printInferred() {
+	dom, prob := parseArgs("-infer-types")
+	if prob == nil {
+		log.Fatal("-infer-types requires a domain and a problem")
+	}
+	if errs := pddl.Check(dom, prob); len(errs) > 0 {
+		for _, err := range errs {
+			log.Println(err)
 		}
-		file.Close()
+		os.Exit(1)
+	}
+	if err := pddl.InferTypes(dom, prob); err != nil {
+		log.Fatal(err)
+	}
+	pddl.PrintDomain(os.Stdout, dom)
+	pddl.PrintProblem(os.Stdout, prob)
+}
+
+// PrintFixedReqs prints the domain and optional problem given
+// as arguments with their requirements replaced by the
+// minimal requirements that they need.
+func printFixedReqs() {
+	dom, prob := parseArgs("-fix-requirements")
+	failed := false
+	for _, err := range pddl.Check(dom, prob) {
+		if _, ok := err.(pddl.MissingRequirementError); !ok {
+			log.Println(err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+	pddl.FixRequirements(dom, prob)
+	pddl.PrintDomain(os.Stdout, dom)
+	if prob != nil {
+		pddl.PrintProblem(os.Stdout, prob)
+	}
+}
+
+// ParseArgs returns the domain and the optional problem defined
+// in the files given as arguments.  The files may define at most
+// one domain and one problem.
+func parseArgs(mode string) (dom *pddl.Domain, prob *pddl.Problem) {
+	for _, path := range flag.Args() {
+		for _, ast := range parseFile(path) {
+			switch r := ast.(type) {
+			case *pddl.Domain:
+				if dom != nil {
+					log.Fatalf("%s requires a single domain", mode)
+				}
+				dom = r
+			case *pddl.Problem:
+				if prob != nil {
+					log.Fatalf("%s requires at most one problem", mode)
+				}
+				prob = r
+			}
+		}
+	}
+	if dom == nil {
+		log.Fatalf("%s requires a domain", mode)
+	}
+	return dom, prob
+}
+
+// ParseFile returns each define in a file.
+func parseFile(path string) []interface{} {
+	file, err := pddl.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	asts, ws, err := pddl.ParseAllWithOptions(path, file, opts)
+	printWarnings(ws)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return asts
+}
+
+func printWarnings(ws []pddl.Warning) {
+	for _, w := range ws {
+		log.Print("warning: ", w)
 	}
 }
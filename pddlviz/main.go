@@ -0,0 +1,353 @@
+// © 2013 the PlanIt Authors under the MIT license. See AUTHORS for the list of authors.
+
+// pddlviz prints Graphviz DOT visualisations of PDDL
+// domains and problems.
+//
+// Usage:
+//
+//	pddlviz [-format dot|json] <mode> <domain> [<problem>]
+//
+// The modes are:
+//
+//	types	the type hierarchy of the domain.
+//		If a problem is given then each type
+//		is labeled with its number of objects.
+//		Either types and the constants, objects,
+//		and parameters using them are in red.
+//	causal	the lifted causal graph of the domain.
+//		There is an arc from predicate p to q
+//		if an action reads p and writes q, or if
+//		it writes both p and q.  Each arc is
+//		labeled with the actions that cause it.
+//		This mode also supports JSON output.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"planit/pddl"
+	"sort"
+	"strings"
+)
+
+var format = flag.String("format", "dot", "output format: dot or json")
+
+// A printer prints a visualisation of a domain and
+// an optional problem.
+type printer func(io.Writer, *pddl.Domain, *pddl.Problem)
+
+// Modes maps each mode name to the printers
+// of its visualisation, by output format.
+var modes = map[string]map[string]printer{
+	"types": {"dot": printTypes},
+	"causal": {
+		"dot":  printCausalDot,
+		"json": printCausalJSON,
+	},
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	log.SetFlags(0)
+	if flag.NArg() < 2 || flag.NArg() > 3 {
+		usage()
+		os.Exit(1)
+	}
+	mode, ok := modes[flag.Arg(0)]
+	if !ok {
+		log.Fatalf("unknown mode %s", flag.Arg(0))
+	}
+	viz, ok := mode[*format]
+	if !ok {
+		log.Fatalf("mode %s does not support format %s", flag.Arg(0), *format)
+	}
+
+	ast, err := parseFile(flag.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+	dom, ok := ast.(*pddl.Domain)
+	if !ok {
+		log.Fatalf("%s is not a domain", flag.Arg(1))
+	}
+	var prob *pddl.Problem
+	if flag.NArg() > 2 {
+		ast, err := parseFile(flag.Arg(2))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if prob, ok = ast.(*pddl.Problem); !ok {
+			log.Fatalf("%s is not a problem", flag.Arg(2))
+		}
+	}
+
+	// Semantic errors are not fatal: the
+	// visualisations are meant to help find them.
+	pddl.Check(dom, prob)
+	viz(os.Stdout, dom, prob)
+}
+
+func usage() {
+	var ms []string
+	for m := range modes {
+		ms = append(ms, m)
+	}
+	sort.Strings(ms)
+	fmt.Fprintf(os.Stderr, "usage: %s [flags] <%s> <domain> [<problem>]\n",
+		os.Args[0], strings.Join(ms, "|"))
+	flag.PrintDefaults()
+}
+
+func parseFile(path string) (interface{}, error) {
+	file, err := pddl.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return pddl.Parse(path, file)
+}
+
+// PrintTypes prints the type hierarchy of the domain with an edge from
+// each type to each of its declared super types.  Types that are declared
+// with an either super type have their either edges dashed and are drawn
+// in red, and types that are referenced but never declared are drawn
+// dashed.  Constants, objects, and parameters that are typed with an
+// either type are drawn as a red box for each either type, labeled with
+// the entries, with dashed edges to its types.  If the problem is non-nil
+// then each type is labeled with the number of objects and constants in
+// its domain.
+func printTypes(w io.Writer, d *pddl.Domain, p *pddl.Problem) {
+	fmt.Fprintf(w, "digraph %q {\n", d.Name.Str)
+	fmt.Fprintln(w, "\trankdir=BT;")
+	for _, t := range d.Types {
+		attrs := []string{"label=" + typeLabel(t, p != nil)}
+		if len(t.Types) > 1 {
+			attrs = append(attrs, "color=red")
+		}
+		fmt.Fprintf(w, "\t%q [%s];\n", t.Str, strings.Join(attrs, ", "))
+	}
+	for _, n := range undeclaredTypes(d, p) {
+		fmt.Fprintf(w, "\t%q [label=%q, style=dashed];\n", n, n+"\n(undeclared)")
+	}
+	for _, e := range eitherEntries(d, p) {
+		fmt.Fprintf(w, "\t%q [label=%q, shape=box, color=red];\n",
+			e.name, e.name+"\n"+strings.Join(e.entries, "\n"))
+		for _, t := range e.types {
+			fmt.Fprintf(w, "\t%q -> %q [style=dashed, color=red];\n", e.name, superName(t))
+		}
+	}
+	for _, t := range d.Types {
+		for _, s := range t.Types {
+			if len(t.Types) > 1 {
+				fmt.Fprintf(w, "\t%q -> %q [style=dashed, color=red, label=\"either\"];\n",
+					t.Str, superName(s))
+				continue
+			}
+			fmt.Fprintf(w, "\t%q -> %q;\n", t.Str, superName(s))
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// TypeLabel returns the quoted DOT label for a type.
+func typeLabel(t pddl.Type, objs bool) string {
+	if !objs {
+		return fmt.Sprintf("%q", t.Str)
+	}
+	n := len(t.Domain)
+	s := "objects"
+	if n == 1 {
+		s = s[:len(s)-1]
+	}
+	return fmt.Sprintf("%q", fmt.Sprintf("%s\n%d %s", t.Str, n, s))
+}
+
+// SuperName returns the name of the node for a super type.
+// Declared types use the spelling of their definition so that
+// edges meet the declared node regardless of case.
+func superName(t pddl.TypeName) string {
+	if t.Definition != nil {
+		return t.Definition.Str
+	}
+	return strings.ToLower(t.Str)
+}
+
+// An either is an either type used by constants,
+// objects, or parameters.
+type either struct {
+	// name is the either type, such as (either a b).
+	name string
+
+	// types are the types of the either.
+	types []pddl.TypeName
+
+	// entries describe the constants, objects,
+	// and parameters with the either type.
+	entries []string
+}
+
+// EitherEntries returns the either types of the constants, objects, and
+// parameters of the domain and problem, in the order of their first use.
+func eitherEntries(d *pddl.Domain, p *pddl.Problem) []*either {
+	var eithers []*either
+	byName := make(map[string]*either)
+	add := func(owner string, es []pddl.TypedEntry) {
+		for _, e := range es {
+			if len(e.Types) < 2 {
+				continue
+			}
+			var names []string
+			for _, t := range e.Types {
+				names = append(names, superName(t))
+			}
+			name := "(either " + strings.Join(names, " ") + ")"
+			ei := byName[name]
+			if ei == nil {
+				ei = &either{name: name, types: e.Types}
+				byName[name] = ei
+				eithers = append(eithers, ei)
+			}
+			ei.entries = append(ei.entries, strings.TrimSpace(owner+" "+e.Str))
+		}
+	}
+	add("", d.Constants)
+	for _, pred := range d.Predicates {
+		add(pred.Str, pred.Parameters)
+	}
+	for _, f := range d.Functions {
+		add(f.Str, f.Parameters)
+	}
+	for _, a := range d.Happenings() {
+		add(a.Str, a.Parameters)
+	}
+	if p != nil {
+		add("", p.Objects)
+	}
+	return eithers
+}
+
+// UndeclaredTypes returns the sorted, lower-case names of all
+// types that are referenced in the domain or problem but that
+// have no definition.
+func undeclaredTypes(d *pddl.Domain, p *pddl.Problem) []string {
+	seen := make(map[string]bool)
+	add := func(ts []pddl.TypeName) {
+		for _, t := range ts {
+			if t.Definition == nil {
+				seen[strings.ToLower(t.Str)] = true
+			}
+		}
+	}
+	addEntries := func(es []pddl.TypedEntry) {
+		for _, e := range es {
+			add(e.Types)
+		}
+	}
+	for _, t := range d.Types {
+		add(t.Types)
+	}
+	addEntries(d.Constants)
+	for _, pred := range d.Predicates {
+		addEntries(pred.Parameters)
+	}
+	for _, f := range d.Functions {
+		addEntries(f.Parameters)
+	}
+	for _, a := range d.Happenings() {
+		addEntries(a.Parameters)
+	}
+	if p != nil {
+		addEntries(p.Objects)
+	}
+	var names []string
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// An arc is an arc in the lifted causal graph.
+type arc struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Actions []string `json:"actions"`
+}
+
+// CausalArcs returns the arcs of the lifted causal graph of the domain.
+// There is an arc from p to q if an action reads p and writes q, or if
+// it writes both p and q (with p ≠ q).  The arcs are returned in the
+// order in which they are first caused by the actions.  Processes and
+// events cause arcs in the same way as actions.
+func causalArcs(d *pddl.Domain) []*arc {
+	var arcs []*arc
+	index := make(map[[2]*pddl.Predicate]*arc)
+	add := func(p, q *pddl.Predicate, act string) {
+		k := [2]*pddl.Predicate{p, q}
+		a := index[k]
+		if a == nil {
+			a = &arc{From: p.Str, To: q.Str}
+			index[k] = a
+			arcs = append(arcs, a)
+		}
+		if n := len(a.Actions); n == 0 || a.Actions[n-1] != act {
+			a.Actions = append(a.Actions, act)
+		}
+	}
+	for _, act := range d.Happenings() {
+		writes := act.Writes()
+		for _, p := range act.Reads() {
+			for _, q := range writes {
+				if p != q {
+					add(p, q, act.Str)
+				}
+			}
+		}
+		for _, p := range writes {
+			for _, q := range writes {
+				if p != q {
+					add(p, q, act.Str)
+				}
+			}
+		}
+	}
+	return arcs
+}
+
+// PrintCausalDot prints the lifted causal graph of the domain in DOT.
+func printCausalDot(w io.Writer, d *pddl.Domain, _ *pddl.Problem) {
+	fmt.Fprintf(w, "digraph %q {\n", d.Name.Str)
+	for _, p := range d.Predicates {
+		fmt.Fprintf(w, "\t%q;\n", p.Str)
+	}
+	for _, a := range causalArcs(d) {
+		fmt.Fprintf(w, "\t%q -> %q [label=%q];\n",
+			a.From, a.To, strings.Join(a.Actions, "\n"))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// PrintCausalJSON prints the lifted causal graph of the domain in JSON.
+func printCausalJSON(w io.Writer, d *pddl.Domain, _ *pddl.Problem) {
+	graph := struct {
+		Domain     string   `json:"domain"`
+		Predicates []string `json:"predicates"`
+		Arcs       []*arc   `json:"arcs"`
+	}{
+		Domain: d.Name.Str,
+		Arcs:   causalArcs(d),
+	}
+	for _, p := range d.Predicates {
+		graph.Predicates = append(graph.Predicates, p.Str)
+	}
+	b, err := json.MarshalIndent(graph, "", "\t")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(w, "%s\n", b)
+}